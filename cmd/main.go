@@ -3,25 +3,71 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 
+	"github.com/google/uuid"
+	"github.com/psevdocoder/gentleman-ping-bot/internal/alert"
 	"github.com/psevdocoder/gentleman-ping-bot/internal/apiclient"
+	"github.com/psevdocoder/gentleman-ping-bot/internal/authguard"
 	"github.com/psevdocoder/gentleman-ping-bot/internal/config"
+	"github.com/psevdocoder/gentleman-ping-bot/internal/credentials"
 	"github.com/psevdocoder/gentleman-ping-bot/internal/curlparse"
+	"github.com/psevdocoder/gentleman-ping-bot/internal/scheduler"
 	"github.com/psevdocoder/gentleman-ping-bot/internal/sender"
 	"github.com/psevdocoder/gentleman-ping-bot/pkg/cron"
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/instancelock"
 	"github.com/psevdocoder/gentleman-ping-bot/pkg/realtimeconfig"
 )
 
+const (
+	defaultShutdownTimeout  = 30 * time.Second
+	defaultLeaseTTL         = 30 * time.Second
+	defaultOutboxStaleAfter = 24 * time.Hour
+	defaultAuthFailures     = 3
+)
+
+// defaultCredentialWarnBefore За сколько до истечения cookie предупреждать по умолчанию
+var defaultCredentialWarnBefore = []time.Duration{72 * time.Hour, 24 * time.Hour}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "outbox" {
+		os.Exit(runOutboxCommand(os.Args[2:]))
+	}
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
 	if err := realtimeconfig.StartWatching(); err != nil {
 		log.Fatal(err)
 	}
 
+	instanceLock, err := acquireInstanceLock(signalCtx)
+	if err != nil {
+		if signalCtx.Err() != nil {
+			log.Println("Stopped in standby")
+			return
+		}
+		log.Fatal(err)
+	}
+
+	leaseLost := make(chan error, 1)
+	if instanceLock != nil {
+		leaseCtx, stopLease := context.WithCancel(context.Background())
+		defer stopLease()
+
+		go instanceLock.KeepAlive(leaseCtx, func(err error) {
+			leaseLost <- err
+		})
+	}
+
 	curlFilePathRaw, err := config.GetValue(config.CurlFile)
 	if err != nil {
 		log.Fatal(err)
@@ -41,67 +87,389 @@ func main() {
 	curlRaw, err := io.ReadAll(file)
 
 	parser := curlparse.NewParser(string(curlRaw))
-	client := apiclient.NewClient()
-	senderJob, err := sender.NewSendMessageJob(parser, client)
+
+	var jarFile string
+	if jarFileRaw, err := config.GetValue(config.CookieJarFile); err == nil {
+		if jarFile, err = jarFileRaw.String(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	jar, err := apiclient.LoadJar(jarFile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	cronManager := cron.NewCronManager()
-	cronManager.Start()
+	client := apiclient.NewClient(jar)
 
-	ctx := context.Background()
+	if retryPolicyRaw, err := config.GetValue(config.RetryPolicy); err == nil {
+		if err := applyRetryPolicy(client, retryPolicyRaw); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	config.Watch(config.RetryPolicy, func(newValue, _ realtimeconfig.Value) {
+		if err := applyRetryPolicy(client, newValue); err != nil {
+			log.Println("Failed to apply retry policy from live config:", err)
+		}
+	})
 
-	senderCronSpecraw, err := config.GetValue(config.CronExpr)
+	alerter, err := newAlerter()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	senderCronSpecStr, err := senderCronSpecraw.String()
+	authFailures := defaultAuthFailures
+	if authFailuresRaw, err := config.GetValue(config.AuthFailureThreshold); err == nil {
+		if authFailures, err = authFailuresRaw.Int(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	guard := authguard.NewGuard(client, authFailures, alerter)
+
+	inspector, err := newCredentialInspector(sessionCookies(jar, parser), alerter)
 	if err != nil {
 		log.Fatal(err)
 	}
+	inspector.Refresh(fileModTime(curlFilePath))
 
-	config.Watch(config.CronExpr, func(newValue, oldValue realtimeconfig.Value) {
-		newCronSpecStr, err := newValue.String()
+	// Сессия, продленная сервером через Set-Cookie, получает новый срок и новые предупреждения
+	jar.OnUpdate(func() {
+		inspector.Refresh(time.Now())
+	})
+
+	var ledgerFile string
+	if ledgerFileRaw, err := config.GetValue(config.SentLedgerFile); err == nil {
+		if ledgerFile, err = ledgerFileRaw.String(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	ledger, err := sender.LoadLedger(ledgerFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var poolFile string
+	if poolFileRaw, err := config.GetValue(config.MessagePoolFile); err == nil {
+		if poolFile, err = poolFileRaw.String(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	pool, err := sender.LoadPool(poolFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outbox, err := loadOutbox()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outboxStaleAfter := defaultOutboxStaleAfter
+	if staleAfterRaw, err := config.GetValue(config.OutboxStaleAfter); err == nil {
+		if outboxStaleAfter, err = staleAfterRaw.Duration(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Свежий curl-файл из DevTools снимает паузу после истекшей сессии
+	lastCurl := string(curlRaw)
+	err = realtimeconfig.WatchFile(curlFilePath, func() {
+		curlRaw, err := os.ReadFile(curlFilePath)
 		if err != nil {
-			log.Println("Failed to parse cron expression in live config:", err)
+			log.Println("Failed to read curl file:", err)
 			return
 		}
+		if len(curlRaw) == 0 || string(curlRaw) == lastCurl {
+			return
+		}
+		lastCurl = string(curlRaw)
+
+		parser.Reload(lastCurl)
+		log.Println("Applied new curl file")
+
+		inspector.Refresh(fileModTime(curlFilePath))
+		guard.Resume()
+		if err := outbox.RetryNow(); err != nil {
+			log.Println("Failed to reschedule outbox:", err)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		oldCronSpecStr, err := oldValue.String()
+	location := time.Local
+	if timezoneRaw, err := config.GetValue(config.Timezone); err == nil {
+		timezone, err := timezoneRaw.String()
 		if err != nil {
-			log.Println("Failed to parse old cron expression in live config:", err)
-			return
+			log.Fatal(err)
+		}
+
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	calendar := cron.NewCalendar()
+	if calendarFileRaw, err := config.GetValue(config.CalendarFile); err == nil {
+		calendarFile, err := calendarFileRaw.String()
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		if err := cronManager.RemoveTask(senderJob.Name()); err != nil {
-			if errors.Is(err, cron.ErrSpecifiedTaskNotFound) {
-				// noop, that's ok
-			} else {
-				log.Println("Failed to remove task:", err)
+		if err := calendar.Load(calendarFile); err != nil {
+			log.Fatal(err)
+		}
+
+		err = realtimeconfig.WatchFile(calendarFile, func() {
+			if err := calendar.Load(calendarFile); err != nil {
+				log.Println("Failed to reload calendar:", err)
+				return
 			}
+
+			log.Println("Applied new calendar")
+		})
+		if err != nil {
+			log.Fatal(err)
 		}
+	}
+
+	cronOpts := []cron.Option{
+		cron.WithLocation(location),
+		cron.WithCalendar(calendar),
+	}
+
+	if stateFileRaw, err := config.GetValue(config.StateFile); err == nil {
+		stateFile, err := stateFileRaw.String()
+		if err != nil {
+			log.Fatal(err)
+		}
+		cronOpts = append(cronOpts, cron.WithStateFile(stateFile))
+	}
+
+	if catchUpWindowRaw, err := config.GetValue(config.CatchUpWindow); err == nil {
+		catchUpWindow, err := catchUpWindowRaw.Duration()
+		if err != nil {
+			log.Fatal(err)
+		}
+		cronOpts = append(cronOpts, cron.WithCatchUpWindow(catchUpWindow))
+	}
 
-		if err := cronManager.AddTask(ctx, newCronSpecStr, senderJob); err != nil {
-			log.Println("Failed to add task in live config:", err)
+	cronManager, err := cron.NewCronManager(cronOpts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	jobs, err := config.GetJobs()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jobScheduler := scheduler.NewScheduler(ctx, cronManager, func(job config.Job) (cron.Task, error) {
+		return sender.NewSendMessageJob(job, parser, guard, ledger, pool, outbox)
+	})
+
+	config.WatchJobs(func(jobs []config.Job) {
+		if err := jobScheduler.Apply(jobs); err != nil {
+			log.Println("Failed to apply jobs from live config:", err)
 			return
 		}
 
-		log.Printf("Changed sender cron from %s to %s", oldCronSpecStr, newCronSpecStr)
+		log.Println("Applied jobs from live config")
 	})
 
-	if err := cronManager.AddTask(ctx, senderCronSpecStr, senderJob); err != nil {
+	if err := jobScheduler.Apply(jobs); err != nil {
 		log.Fatal(err)
 	}
 
-	syscallCh := make(chan os.Signal, 1)
-	signal.Notify(syscallCh, syscall.SIGINT, syscall.SIGTERM)
-	<-syscallCh
+	cronManager.Start()
 
-	if err := cronManager.Stop(ctx); err != nil {
-		log.Fatal(err)
+	outboxCtx, stopOutbox := context.WithCancel(context.Background())
+	outboxDone := make(chan struct{})
+	go func() {
+		defer close(outboxDone)
+		sender.NewOutboxWorker(outbox, parser, guard, ledger, outboxStaleAfter).Run(outboxCtx)
+	}()
+
+	inspectorCtx, stopInspector := context.WithCancel(context.Background())
+	inspectorDone := make(chan struct{})
+	go func() {
+		defer close(inspectorDone)
+		inspector.Run(inspectorCtx)
+	}()
+
+	for _, task := range cronManager.List() {
+		log.Printf("Scheduled %s (%s, %s), next run at %s", task.Name, task.Spec, task.Location, task.Next.In(task.Location).Format(time.RFC3339))
+	}
+
+	exitCode := 0
+	leaseHeld := instanceLock != nil
+	select {
+	case <-signalCtx.Done():
+		log.Println("Shutting down...")
+	case err := <-leaseLost:
+		log.Println("Instance lease lost, shutting down:", err)
+		exitCode = 1
+		leaseHeld = false
+	}
+
+	shutdownTimeout := defaultShutdownTimeout
+	if shutdownTimeoutRaw, err := config.GetValue(config.ShutdownTimeout); err == nil {
+		if shutdownTimeout, err = shutdownTimeoutRaw.Duration(); err != nil {
+			log.Println("Failed to parse shutdown timeout, using default:", err)
+			shutdownTimeout = defaultShutdownTimeout
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := realtimeconfig.StopWatching(); err != nil {
+		log.Println("Failed to stop config watchers:", err)
+	}
+
+	stopOutbox()
+	<-outboxDone
+	stopInspector()
+	<-inspectorDone
+
+	// прерванные задачи не мешают освободить аренду: иначе резервный экземпляр ждал бы весь ttl
+	if err := cronManager.Stop(shutdownCtx); err != nil {
+		var stopErr *cron.StopError
+		if errors.As(err, &stopErr) {
+			log.Printf("Aborted tasks on shutdown: %v", stopErr.Tasks)
+		}
+		log.Println("Failed to stop cron:", err)
+		exitCode = 1
+	}
+
+	if leaseHeld {
+		if err := instanceLock.Release(); err != nil {
+			log.Println("Failed to release instance lease:", err)
+		}
+	}
+
+	log.Println("Stopped")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// acquireInstanceLock ждет аренды единственного активного экземпляра, если она настроена.
+// Пока аренда у другого экземпляра, этот стоит в резерве и задачи не планирует
+func acquireInstanceLock(ctx context.Context) (*instancelock.Lock, error) {
+	lockFileRaw, err := config.GetValue(config.InstanceLockFile)
+	if err != nil {
+		return nil, nil
+	}
+
+	lockFile, err := lockFileRaw.String()
+	if err != nil {
+		return nil, err
+	}
+
+	leaseTTL := defaultLeaseTTL
+	if leaseTTLRaw, err := config.GetValue(config.InstanceLeaseTTL); err == nil {
+		if leaseTTL, err = leaseTTLRaw.Duration(); err != nil {
+			return nil, err
+		}
+	}
+
+	owner, err := os.Hostname()
+	if err != nil {
+		owner = "unknown"
+	}
+	owner = fmt.Sprintf("%s/%d/%s", owner, os.Getpid(), uuid.NewString())
+
+	lock, err := instancelock.NewLock(lockFile, owner, leaseTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lock.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Acquired instance lease %s as %s", lockFile, owner)
+	return lock, nil
+}
+
+// applyRetryPolicy накладывает заданные в конфиге поля политики повторов на значения по умолчанию
+func applyRetryPolicy(client *apiclient.Client, raw realtimeconfig.Value) error {
+	policy := apiclient.DefaultRetryPolicy()
+	if err := raw.Decode(&policy); err != nil {
+		return fmt.Errorf("parse retry policy: %w", err)
+	}
+
+	return client.SetRetryPolicy(policy)
+}
+
+// newAlerter создает резервный канал оповещений из values.alert, без настройки оповещения пишутся в лог
+func newAlerter() (alert.Alerter, error) {
+	var cfg alert.Config
+	if alertRaw, err := config.GetValue(config.Alert); err == nil {
+		if err := alertRaw.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("parse alert config: %w", err)
+		}
+	}
+
+	return alert.New(cfg)
+}
+
+// newCredentialInspector создает проверку срока действия cookie с порогами из values.credential_warn_before
+func newCredentialInspector(source credentials.CookieSource, alerter alert.Alerter) (*credentials.Inspector, error) {
+	warnBefore := defaultCredentialWarnBefore
+	if warnBeforeRaw, err := config.GetValue(config.CredentialWarnBefore); err == nil {
+		warnBefore = nil
+		if err := warnBeforeRaw.Decode(&warnBefore); err != nil {
+			return nil, fmt.Errorf("parse credential warn before: %w", err)
+		}
+	}
+
+	var maxAge time.Duration
+	if maxAgeRaw, err := config.GetValue(config.CredentialMaxAge); err == nil {
+		if maxAge, err = maxAgeRaw.Duration(); err != nil {
+			return nil, err
+		}
 	}
 
-	log.Println("Shutting down...")
+	return credentials.NewInspector(source, alerter, warnBefore, maxAge), nil
+}
+
+// sessionCookies Текущие cookie сессии: из jar, засеянного cookie из curl-файла и обновленного сервером
+func sessionCookies(jar *apiclient.Jar, parser *curlparse.Parser) credentials.CookieSource {
+	return func() (string, error) {
+		requestURL, err := parser.GetRequestURL()
+		if err != nil {
+			return "", err
+		}
+
+		u, err := url.Parse(requestURL)
+		if err != nil {
+			return "", err
+		}
+
+		cookie, err := parser.GetCookie()
+		if err != nil {
+			return "", err
+		}
+
+		jar.Seed(u, cookie)
+		return jar.Header(u), nil
+	}
+}
+
+// fileModTime Время изменения файла, нулевое, если его не удалось узнать
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/config"
+	"github.com/psevdocoder/gentleman-ping-bot/internal/sender"
+)
+
+// loadOutbox открывает очередь из values.outbox_file, без настройки очередь живет только в памяти
+func loadOutbox() (*sender.Outbox, error) {
+	var outboxFile string
+	if outboxFileRaw, err := config.GetValue(config.OutboxFile); err == nil {
+		if outboxFile, err = outboxFileRaw.String(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sender.LoadOutbox(outboxFile)
+}
+
+// runOutboxCommand выполняет команду "outbox list" или "outbox purge [-all]" и возвращает код выхода
+func runOutboxCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: outbox list | outbox purge [-all]")
+		return 2
+	}
+
+	outbox, err := loadOutbox()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch args[0] {
+	case "list":
+		entries, err := outbox.List()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "UUID\tJOB\tCHAT\tENQUEUED\tATTEMPTS\tSTATE\tNEXT ATTEMPT\tLAST ERROR")
+		for _, e := range entries {
+			state, next := "pending", e.NextAttempt.Format(time.RFC3339)
+			if e.Stale {
+				state, next = "stale", "-"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\t%s\t%s\n",
+				e.UUID, e.Job, e.Body.ChatID, e.EnqueuedAt.Format(time.RFC3339), e.Attempts, state, next, e.LastError)
+		}
+		_ = w.Flush()
+		fmt.Printf("%d messages\n", len(entries))
+
+		return 0
+	case "purge":
+		fs := flag.NewFlagSet("outbox purge", flag.ContinueOnError)
+		all := fs.Bool("all", false, "remove pending messages too, not only stale ones")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		removed, err := outbox.Purge(!*all)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Removed %d messages\n", removed)
+
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown outbox command %q\n", args[0])
+		return 2
+	}
+}
@@ -0,0 +1,150 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// Alerter Резервный канал оповещений о проблемах бота, не зависящий от API мессенджера
+type Alerter interface {
+	Alert(ctx context.Context, subject string, text string) error
+}
+
+// Config Настройки канала оповещений из values.alert
+type Config struct {
+	// Type Канал: webhook, smtp, file или log (по умолчанию)
+	Type string `yaml:"type"`
+	// URL Адрес для webhook, получает POST с JSON {"subject", "text", "time"}
+	URL string `yaml:"url"`
+	// Addr Адрес SMTP-сервера host:port, From и To - отправитель и получатели письма
+	Addr     string   `yaml:"addr"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	// Path Файл, в который дописываются оповещения
+	Path string `yaml:"path"`
+}
+
+// New создает канал оповещений по настройкам
+func New(cfg Config) (Alerter, error) {
+	switch strings.ToLower(cfg.Type) {
+	case "", "log":
+		return Log{}, nil
+	case "webhook":
+		if cfg.URL == "" {
+			return nil, errors.New("alert webhook url is empty")
+		}
+		return &Webhook{url: cfg.URL, client: &http.Client{Timeout: 30 * time.Second}}, nil
+	case "smtp":
+		if cfg.Addr == "" || cfg.From == "" || len(cfg.To) == 0 {
+			return nil, errors.New("alert smtp requires addr, from and to")
+		}
+		return &SMTP{addr: cfg.Addr, from: cfg.From, to: cfg.To, username: cfg.Username, password: cfg.Password}, nil
+	case "file":
+		if cfg.Path == "" {
+			return nil, errors.New("alert file path is empty")
+		}
+		return &File{path: cfg.Path}, nil
+	default:
+		return nil, fmt.Errorf("unknown alert type %q", cfg.Type)
+	}
+}
+
+// Log Пишет оповещения в лог бота
+type Log struct{}
+
+func (Log) Alert(_ context.Context, subject string, text string) error {
+	log.Printf("ALERT %s: %s", subject, text)
+	return nil
+}
+
+// Webhook Отправляет оповещения POST-запросом на произвольный адрес
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func (w *Webhook) Alert(ctx context.Context, subject string, text string) error {
+	body, err := json.Marshal(map[string]string{
+		"subject": subject,
+		"text":    text,
+		"time":    time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// SMTP Отправляет оповещения письмом через SMTP-сервер, обычно локальный
+type SMTP struct {
+	addr     string
+	from     string
+	to       []string
+	username string
+	password string
+}
+
+func (s *SMTP) Alert(_ context.Context, subject string, text string) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		host, _, err := net.SplitHostPort(s.addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.username, s.password, host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.from, strings.Join(s.to, ", "), mime.QEncoding.Encode("utf-8", subject), text)
+
+	return smtp.SendMail(s.addr, auth, s.from, s.to, []byte(msg))
+}
+
+// File Дописывает оповещения в файл
+type File struct {
+	path string
+}
+
+func (f *File) Alert(_ context.Context, subject string, text string) error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(file, "%s %s: %s\n", time.Now().Format(time.RFC3339), subject, text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -2,7 +2,9 @@ package apiclient
 
 import (
 	"io"
+	"log"
 	"net/http"
+	"sync/atomic"
 )
 
 type httpClient interface {
@@ -10,13 +12,45 @@ type httpClient interface {
 }
 
 type Client struct {
-	client httpClient
+	client      httpClient
+	jar         *Jar
+	retryPolicy atomic.Pointer[RetryPolicy]
 }
 
-func NewClient() *Client {
-	return &Client{
-		client: &http.Client{},
+// NewClient создает клиент, который берет cookie из jar и сохраняет в него Set-Cookie из ответов
+func NewClient(jar *Jar) *Client {
+	c := &Client{
+		jar: jar,
+		client: &http.Client{
+			// редирект на POST обычно ведет на страницу входа, его разбирает statusError
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
 	}
+
+	policy := DefaultRetryPolicy()
+	c.retryPolicy.Store(&policy)
+
+	return c
+}
+
+// SetRetryPolicy подменяет политику повторов. Уже идущая отправка доделывается по прежней
+func (c *Client) SetRetryPolicy(policy RetryPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	c.retryPolicy.Store(&policy)
+	log.Printf("Applied retry policy: %d attempts, backoff %s..%s, jitter %.2f, retry on %v",
+		policy.MaxAttempts, policy.BaseBackoff, policy.MaxBackoff, policy.Jitter, policy.RetryOn)
+
+	return nil
+}
+
+// Retryable сообщает, относится ли ошибка к классам, которые повторяются по текущей политике
+func (c *Client) Retryable(err error) bool {
+	return c.retryPolicy.Load().retryable(err)
 }
 
 func closeAndDiscard(resp *http.Response) {
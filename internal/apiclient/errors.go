@@ -0,0 +1,219 @@
+package apiclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBody Сколько байт тела ответа сохранять в ошибке
+const maxErrorBody = 512
+
+// ErrorClass Класс ошибки отправки, по нему настраивается, что повторять
+type ErrorClass int
+
+const (
+	ClassUnknown ErrorClass = iota
+	// ClassAuth сессия истекла или доступ запрещен: 401, 403 или редирект на страницу входа
+	ClassAuth
+	// ClassRateLimited слишком много запросов: 429
+	ClassRateLimited
+	// ClassClient остальные ответы 4xx
+	ClassClient
+	// ClassServer ответы 5xx
+	ClassServer
+	// ClassNetwork запрос не дошел или ответ не прочитан
+	ClassNetwork
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ClassAuth:
+		return "auth"
+	case ClassRateLimited:
+		return "rate_limited"
+	case ClassClient:
+		return "client"
+	case ClassServer:
+		return "server"
+	case ClassNetwork:
+		return "network"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseErrorClass разбирает класс ошибки из конфига
+func ParseErrorClass(s string) (ErrorClass, error) {
+	for c := ClassAuth; c <= ClassNetwork; c++ {
+		if strings.EqualFold(strings.TrimSpace(s), c.String()) {
+			return c, nil
+		}
+	}
+	return ClassUnknown, fmt.Errorf("unknown error class %q", s)
+}
+
+// AuthError API отверг cookie: сессия истекла или нет прав. Location заполнен, если API отправил на страницу входа
+type AuthError struct {
+	StatusCode int
+	Location   string
+	Body       string
+}
+
+func (e *AuthError) Error() string {
+	if e.Location != "" {
+		return fmt.Sprintf("auth failed: status %d, redirected to %s", e.StatusCode, e.Location)
+	}
+	return fmt.Sprintf("auth failed: status %d: %s", e.StatusCode, e.Body)
+}
+
+// RateLimitError API просит повторить позже. RetryAfter нулевой, если сервер не указал срок
+type RateLimitError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited: status %d, retry after %s: %s", e.StatusCode, e.RetryAfter, e.Body)
+}
+
+// ClientError Запрос отвергнут как некорректный, повтор не поможет
+type ClientError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("client error: status %d: %s", e.StatusCode, e.Body)
+}
+
+// ServerError Ошибка на стороне API. RetryAfter заполняется, если сервер его указал (обычно с 503)
+type ServerError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server error: status %d: %s", e.StatusCode, e.Body)
+}
+
+// NetworkError Запрос не дошел до API или ответ не удалось прочитать
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return "network error: " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// Classify возвращает класс ошибки отправки
+func Classify(err error) ErrorClass {
+	var (
+		authErr      *AuthError
+		rateLimitErr *RateLimitError
+		clientErr    *ClientError
+		serverErr    *ServerError
+		networkErr   *NetworkError
+	)
+
+	switch {
+	case errors.As(err, &authErr):
+		return ClassAuth
+	case errors.As(err, &rateLimitErr):
+		return ClassRateLimited
+	case errors.As(err, &clientErr):
+		return ClassClient
+	case errors.As(err, &serverErr):
+		return ClassServer
+	case errors.As(err, &networkErr):
+		return ClassNetwork
+	default:
+		return ClassUnknown
+	}
+}
+
+// retryAfter Срок, который сервер просит выждать перед повтором, если он его указал
+func retryAfter(err error) time.Duration {
+	var (
+		rateLimitErr *RateLimitError
+		serverErr    *ServerError
+	)
+
+	switch {
+	case errors.As(err, &rateLimitErr):
+		return rateLimitErr.RetryAfter
+	case errors.As(err, &serverErr):
+		return serverErr.RetryAfter
+	default:
+		return 0
+	}
+}
+
+// statusError превращает ответ не из 2xx в типизированную ошибку
+func statusError(response *http.Response, body []byte) error {
+	code := response.StatusCode
+	text := string(body)
+	if len(text) > maxErrorBody {
+		text = text[:maxErrorBody] + "..."
+	}
+
+	switch {
+	case code >= 200 && code < 300:
+		return nil
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return &AuthError{StatusCode: code, Body: text}
+	case code >= 300 && code < 400 && isLoginRedirect(response.Header.Get("Location")):
+		return &AuthError{StatusCode: code, Location: response.Header.Get("Location"), Body: text}
+	case code == http.StatusTooManyRequests:
+		return &RateLimitError{StatusCode: code, RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()), Body: text}
+	case code >= 500:
+		serverErr := &ServerError{StatusCode: code, Body: text}
+		if code == http.StatusServiceUnavailable {
+			serverErr.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+		}
+		return serverErr
+	default:
+		return &ClientError{StatusCode: code, Body: text}
+	}
+}
+
+// isLoginRedirect похож ли адрес редиректа на страницу входа
+func isLoginRedirect(location string) bool {
+	location = strings.ToLower(location)
+	for _, marker := range []string{"login", "signin", "sign-in", "auth", "sso"} {
+		if strings.Contains(location, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в виде HTTP-даты
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,124 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/sender"
+)
+
+func TestStatusErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		header     http.Header
+		wantClass  ErrorClass
+		wantRetry  time.Duration
+		wantNilErr bool
+	}{
+		{name: "ok", code: http.StatusOK, wantNilErr: true},
+		{name: "unauthorized", code: http.StatusUnauthorized, wantClass: ClassAuth},
+		{name: "forbidden", code: http.StatusForbidden, wantClass: ClassAuth},
+		{name: "login redirect", code: http.StatusFound, header: http.Header{"Location": {"/sso/login?next=/api"}}, wantClass: ClassAuth},
+		{name: "other redirect", code: http.StatusFound, header: http.Header{"Location": {"/api/v2/messages"}}, wantClass: ClassClient},
+		{name: "rate limited", code: http.StatusTooManyRequests, header: http.Header{"Retry-After": {"7"}}, wantClass: ClassRateLimited, wantRetry: 7 * time.Second},
+		{name: "bad request", code: http.StatusBadRequest, wantClass: ClassClient},
+		{name: "internal error", code: http.StatusInternalServerError, wantClass: ClassServer},
+		{name: "unavailable", code: http.StatusServiceUnavailable, header: http.Header{"Retry-After": {"3"}}, wantClass: ClassServer, wantRetry: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == nil {
+				header = http.Header{}
+			}
+
+			err := statusError(&http.Response{StatusCode: tt.code, Header: header}, []byte("body"))
+			if tt.wantNilErr {
+				if err != nil {
+					t.Fatalf("statusError() = %v, want nil", err)
+				}
+				return
+			}
+
+			if class := Classify(err); class != tt.wantClass {
+				t.Errorf("Classify() = %s, want %s", class, tt.wantClass)
+			}
+
+			var (
+				rateLimitErr *RateLimitError
+				serverErr    *ServerError
+				after        time.Duration
+			)
+			switch {
+			case errors.As(err, &rateLimitErr):
+				after = rateLimitErr.RetryAfter
+			case errors.As(err, &serverErr):
+				after = serverErr.RetryAfter
+			}
+			if after != tt.wantRetry {
+				t.Errorf("RetryAfter = %s, want %s", after, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, time.March, 3, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSendMessageReturnsTypedErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient(mustLoadJar(t))
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	err := client.SendMessage(context.Background(), server.URL, "sid=1", headers, &sender.Body{})
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("SendMessage() error = %v, want *AuthError with status 401", err)
+	}
+
+	server.Close()
+	err = client.SendMessage(context.Background(), server.URL, "sid=1", headers, &sender.Body{})
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) {
+		t.Errorf("SendMessage() to a closed server error = %v, want *NetworkError", err)
+	}
+}
+
+func mustLoadJar(t *testing.T) *Jar {
+	t.Helper()
+
+	jar, err := LoadJar("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jar
+}
@@ -0,0 +1,242 @@
+package apiclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/statefile"
+)
+
+// Jar Хранилище cookie клиента. Засевается cookie из curl-файла, подхватывает Set-Cookie
+// из каждого ответа и сохраняет обновленную сессию в файл, чтобы она пережила рестарт.
+// Cookie хранятся по хосту запроса: бот ходит в один API, атрибут Domain не учитывается
+type Jar struct {
+	mu       sync.Mutex
+	store    statefile.Store
+	onUpdate func()
+	Hosts    map[string]*jarHost `json:"hosts"`
+}
+
+type jarHost struct {
+	// Seed Хеш строки Cookie из curl-файла, которой засеян хост. По нему видно, что curl-файл заменили
+	Seed    string       `json:"seed"`
+	Cookies []*jarCookie `json:"cookies"`
+}
+
+type jarCookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Bare Пара из curl-файла без "=", отправляется как есть
+	Bare    bool      `json:"bare,omitempty"`
+	Path    string    `json:"path,omitempty"`
+	Expires time.Time `json:"expires,omitzero"`
+	Secure  bool      `json:"secure,omitempty"`
+}
+
+// LoadJar читает сохраненные cookie из файла path
+func LoadJar(path string) (*Jar, error) {
+	j := &Jar{store: statefile.NewStore(path, "cookie jar")}
+	if err := j.store.Load(j); err != nil {
+		return nil, err
+	}
+	if j.Hosts == nil {
+		j.Hosts = make(map[string]*jarHost)
+	}
+
+	return j, nil
+}
+
+// Seed засевает хост cookie из curl-файла. Пока curl-файл тот же, сохраненные и обновленные
+// сервером cookie остаются в силе; новый curl-файл полностью заменяет cookie хоста
+func (j *Jar) Seed(u *url.URL, cookie string) {
+	sum := sha256.Sum256([]byte(cookie))
+	seed := hex.EncodeToString(sum[:])
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := u.Hostname()
+	if h, ok := j.Hosts[host]; ok && h.Seed == seed {
+		return
+	}
+
+	h := &jarHost{Seed: seed, Cookies: parseCookieHeader(cookie)}
+	j.Hosts[host] = h
+
+	log.Printf("Cookie jar for %s seeded from curl file: %s", host, redactCookies(h.Cookies))
+	j.save()
+}
+
+// Header собирает заголовок Cookie для запроса, выбрасывая истекшие cookie. Значения уходят как есть,
+// без проверок net/http, которые выбросили бы кавычки, запятые и пробелы из скопированных из браузера cookie
+func (j *Jar) Header(u *url.URL) string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	h, ok := j.Hosts[u.Hostname()]
+	if !ok {
+		return ""
+	}
+
+	now := time.Now()
+	h.Cookies = slices.DeleteFunc(h.Cookies, func(c *jarCookie) bool {
+		return !c.Expires.IsZero() && !c.Expires.After(now)
+	})
+
+	var pairs []string
+	for _, c := range h.Cookies {
+		if c.Secure && u.Scheme != "https" {
+			continue
+		}
+		if c.Path != "" && !strings.HasPrefix(u.Path, c.Path) {
+			continue
+		}
+		pairs = append(pairs, c.pair())
+	}
+
+	return strings.Join(pairs, "; ")
+}
+
+// OnUpdate задает fn, который вызывается после того, как сервер обновил cookie через Set-Cookie
+func (j *Jar) OnUpdate(fn func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.onUpdate = fn
+}
+
+// SetCookies применяет Set-Cookie из ответа и сохраняет хранилище
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+
+	j.mu.Lock()
+
+	host := u.Hostname()
+	h, ok := j.Hosts[host]
+	if !ok {
+		h = &jarHost{}
+		j.Hosts[host] = h
+	}
+
+	now := time.Now()
+	var changed []string
+	for _, c := range cookies {
+		idx := slices.IndexFunc(h.Cookies, func(stored *jarCookie) bool {
+			return stored.Name == c.Name && stored.Path == cookiePath(c)
+		})
+
+		if c.MaxAge < 0 || (c.MaxAge == 0 && !c.Expires.IsZero() && !c.Expires.After(now)) {
+			if idx >= 0 {
+				h.Cookies = slices.Delete(h.Cookies, idx, idx+1)
+			}
+			changed = append(changed, c.Name+" removed")
+			continue
+		}
+
+		value := c.Value
+		if c.Quoted {
+			value = `"` + value + `"`
+		}
+
+		stored := &jarCookie{
+			Name:    c.Name,
+			Value:   value,
+			Path:    cookiePath(c),
+			Expires: c.Expires,
+			Secure:  c.Secure,
+		}
+		if c.MaxAge > 0 {
+			stored.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		if idx >= 0 {
+			h.Cookies[idx] = stored
+		} else {
+			h.Cookies = append(h.Cookies, stored)
+		}
+		changed = append(changed, c.Name+"=<redacted>")
+	}
+
+	log.Printf("Cookie jar for %s updated from response: %s", host, strings.Join(changed, ", "))
+	j.save()
+	onUpdate := j.onUpdate
+	j.mu.Unlock()
+
+	if onUpdate != nil {
+		onUpdate()
+	}
+}
+
+// String Содержимое хранилища без значений cookie, безопасно для логов
+func (j *Jar) String() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	hosts := make([]string, 0, len(j.Hosts))
+	for host, h := range j.Hosts {
+		hosts = append(hosts, host+": "+redactCookies(h.Cookies))
+	}
+	slices.Sort(hosts)
+
+	return strings.Join(hosts, "; ")
+}
+
+// save записывает хранилище в файл. Вызывается под j.mu
+func (j *Jar) save() {
+	j.store.SaveOrLog(j)
+}
+
+func (c *jarCookie) pair() string {
+	if c.Bare {
+		return c.Name
+	}
+	return c.Name + "=" + c.Value
+}
+
+// parseCookieHeader разбирает строку Cookie из curl-файла. В отличие от http.ParseCookie
+// не отвергает значения с кавычками, запятыми и пробелами и пустые пары после лишней ";":
+// все, что браузер отправил, уходит на сервер без изменений
+func parseCookieHeader(cookie string) []*jarCookie {
+	var cookies []*jarCookie
+	for _, part := range strings.Split(cookie, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			cookies = append(cookies, &jarCookie{Name: part, Bare: true})
+			continue
+		}
+		cookies = append(cookies, &jarCookie{Name: strings.TrimSpace(name), Value: value})
+	}
+	return cookies
+}
+
+// cookiePath Путь из Set-Cookie. Корень хранится пустым, как у cookie из curl-файла
+func cookiePath(c *http.Cookie) string {
+	if c.Path == "/" {
+		return ""
+	}
+	return c.Path
+}
+
+// redactCookies Имена cookie со скрытыми значениями, чтобы сессия не попадала в логи
+func redactCookies(cookies []*jarCookie) string {
+	parts := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		parts = append(parts, c.Name+"=<redacted>")
+	}
+
+	return strings.Join(parts, ", ")
+}
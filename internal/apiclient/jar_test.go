@@ -0,0 +1,75 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/sender"
+)
+
+// browserCookie Строка -b, как ее копирует DevTools: JSON и кавычки в значениях, пара без "=" и ";" в конце
+const browserCookie = `_ga=GA1.1.1234567890.1700000000; session={"user":"ivan petrov","roles":["a","b"]}; prefs="a,b c"; flag; theme=dark;`
+
+func TestJarSeedKeepsBrowserCookieAsIs(t *testing.T) {
+	jar, err := LoadJar("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse("https://api.example.com/messages")
+	jar.Seed(u, browserCookie)
+
+	want := `_ga=GA1.1.1234567890.1700000000; session={"user":"ivan petrov","roles":["a","b"]}; prefs="a,b c"; flag; theme=dark`
+	if got := jar.Header(u); got != want {
+		t.Errorf("Header() = %q, want %q", got, want)
+	}
+}
+
+func TestClientSendsBrowserCookieAndAbsorbsSetCookie(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Cookie"))
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "light", Path: "/"})
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := LoadJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient(jar)
+	headers := map[string]string{"Content-Type": "application/json"}
+	for range 2 {
+		if err := client.SendMessage(context.Background(), server.URL, browserCookie, headers, &sender.Body{}); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+	}
+
+	want := []string{
+		`_ga=GA1.1.1234567890.1700000000; session={"user":"ivan petrov","roles":["a","b"]}; prefs="a,b c"; flag; theme=dark`,
+		`_ga=GA1.1.1234567890.1700000000; session={"user":"ivan petrov","roles":["a","b"]}; prefs="a,b c"; flag; theme=light`,
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("request %d Cookie = %q, want %q", i+1, got[i], want[i])
+		}
+	}
+
+	// обновленная сервером cookie переживает рестарт, пока curl-файл тот же
+	restored, err := LoadJar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(server.URL)
+	restored.Seed(u, browserCookie)
+	if cookie := restored.Header(u); cookie != want[1] {
+		t.Errorf("restored Header() = %q, want %q", cookie, want[1])
+	}
+}
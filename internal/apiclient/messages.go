@@ -8,10 +8,15 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/psevdocoder/gentleman-ping-bot/internal/sender"
 )
 
+// SendMessage отправляет сообщение, повторяя попытки по политике повторов. Ответ не из 2xx
+// возвращается типизированной ошибкой (*AuthError, *RateLimitError, *ClientError, *ServerError),
+// сбой сети - *NetworkError. Повторы прекращаются, когда следующая пауза не укладывается в дедлайн ctx
+// или сервер просит Retry-After дольше MaxBackoff
 func (c *Client) SendMessage(ctx context.Context, requestURL string, cookie string, headers map[string]string, messageBody *sender.Body) error {
 	if requestURL == "" {
 		return errors.New("requestURL is empty")
@@ -30,28 +35,75 @@ func (c *Client) SendMessage(ctx context.Context, requestURL string, cookie stri
 		return err
 	}
 
+	policy := c.retryPolicy.Load()
+
+	for attempt := 1; ; attempt++ {
+		err := c.send(ctx, requestURL, cookie, headers, bodyBytes)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return err
+		}
+
+		if after := retryAfter(err); after > policy.MaxBackoff {
+			log.Printf("Client.SendMessage attempt %d failed, server asks to retry in %s, longer than max backoff %s: %v", attempt, after, policy.MaxBackoff, err)
+			return err
+		}
+
+		delay := policy.backoff(attempt, err)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			log.Printf("Client.SendMessage attempt %d failed, no time left to retry before deadline: %v", attempt, err)
+			return err
+		}
+
+		log.Printf("Client.SendMessage attempt %d/%d failed, retrying in %s: %v", attempt, policy.MaxAttempts, delay.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, requestURL string, cookie string, headers map[string]string, bodyBytes []byte) error {
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, requestURL, bytes.NewReader(bodyBytes))
 
 	if err != nil {
 		return err
 	}
 
-	request.Header.Set("Cookie", cookie)
 	for key, value := range headers {
 		request.Header.Set(key, value)
 	}
 
+	// cookie берутся из jar: пока curl-файл тот же, в запрос идут обновленные сервером значения
+	c.jar.Seed(request.URL, cookie)
+	request.Header.Set("Cookie", c.jar.Header(request.URL))
+
 	response, err := c.client.Do(request)
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &NetworkError{Err: err}
 	}
 	defer closeAndDiscard(response)
 
+	c.jar.SetCookies(request.URL, response.Cookies())
+
 	respBytes, err := io.ReadAll(response.Body)
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &NetworkError{Err: err}
 	}
 
 	log.Println("Client.SendMessage response", response.StatusCode, string(respBytes))
-	return nil
+	return statusError(response, respBytes)
 }
@@ -0,0 +1,86 @@
+package apiclient
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy Политика повторов отправки. Настраивается на лету через realtime_config.retry_policy
+type RetryPolicy struct {
+	// MaxAttempts Сколько всего попыток, включая первую
+	MaxAttempts int `yaml:"max_attempts"`
+	// BaseBackoff Пауза перед вторым запросом, дальше удваивается до MaxBackoff
+	BaseBackoff time.Duration `yaml:"base_backoff"`
+	// MaxBackoff Самая долгая пауза. Если сервер просит Retry-After дольше, повторы прекращаются
+	// и сообщение уходит в очередь повторной доставки, а не держит задачу
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// Jitter Доля паузы, на которую она случайно сдвигается в обе стороны, от 0 до 1
+	Jitter float64 `yaml:"jitter"`
+	// RetryOn Классы ошибок, которые повторяются: auth, rate_limited, client, server, network
+	RetryOn []string `yaml:"retry_on"`
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  30 * time.Second,
+		Jitter:      0.2,
+		RetryOn:     []string{ClassRateLimited.String(), ClassServer.String(), ClassNetwork.String()},
+	}
+}
+
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 1 {
+		return errors.New("max_attempts must be at least 1")
+	}
+	if p.BaseBackoff < 0 {
+		return errors.New("base_backoff must not be negative")
+	}
+	if p.MaxBackoff <= 0 {
+		return errors.New("max_backoff must be positive")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1, got %v", p.Jitter)
+	}
+	for _, class := range p.RetryOn {
+		if _, err := ParseErrorClass(class); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	class := Classify(err)
+	for _, name := range p.RetryOn {
+		if c, _ := ParseErrorClass(name); c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff Пауза перед попыткой attempt+1, не дольше MaxBackoff. Retry-After от сервера имеет приоритет над расчетной паузой
+func (p RetryPolicy) backoff(attempt int, err error) time.Duration {
+	if after := retryAfter(err); after > 0 {
+		return min(after, p.MaxBackoff)
+	}
+
+	delay := p.BaseBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration((rand.Float64()*2 - 1) * spread)
+	}
+
+	return delay
+}
@@ -0,0 +1,66 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/sender"
+)
+
+func TestSendMessageGivesUpOnLongRetryAfter(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewClient(mustLoadJar(t))
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	started := time.Now()
+	err := client.SendMessage(context.Background(), server.URL, "sid=1", headers, &sender.Body{})
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("SendMessage() error = %v, want *RateLimitError", err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("SendMessage() blocked for %s on Retry-After: 3600", elapsed)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
+
+func TestBackoffIsCappedByMaxBackoff(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.Jitter = 0
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		want    time.Duration
+	}{
+		{name: "first retry", attempt: 1, err: &ServerError{StatusCode: 500}, want: time.Second},
+		{name: "doubles", attempt: 3, err: &ServerError{StatusCode: 500}, want: 4 * time.Second},
+		{name: "capped", attempt: 10, err: &ServerError{StatusCode: 500}, want: policy.MaxBackoff},
+		{name: "retry after", attempt: 1, err: &RateLimitError{RetryAfter: 5 * time.Second}, want: 5 * time.Second},
+		{name: "long retry after", attempt: 1, err: &RateLimitError{RetryAfter: time.Hour}, want: policy.MaxBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.backoff(tt.attempt, tt.err); got != tt.want {
+				t.Errorf("backoff() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
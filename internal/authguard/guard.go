@@ -0,0 +1,132 @@
+package authguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/alert"
+	"github.com/psevdocoder/gentleman-ping-bot/internal/apiclient"
+	"github.com/psevdocoder/gentleman-ping-bot/internal/sender"
+)
+
+// alertTimeout Лимит на отправку одного оповещения
+const alertTimeout = 30 * time.Second
+
+// ErrPaused Отправка приостановлена после подряд идущих ошибок авторизации до появления свежего curl-файла
+var ErrPaused = errors.New("sending paused after repeated auth failures")
+
+type messageClient interface {
+	SendMessage(ctx context.Context, requestURL string, cookie string, headers map[string]string, messageBody *sender.Body) error
+	Retryable(err error) bool
+}
+
+// Guard Обертка над клиентом API, которая считает подряд идущие ошибки авторизации.
+// После threshold таких ошибок отправка приостанавливается и поднимается оповещение.
+// Сообщения, не отправленные из-за авторизации или паузы, считаются временными ошибками
+// и уходят в outbox, откуда будут доставлены после Resume
+type Guard struct {
+	client    messageClient
+	threshold int
+	alerter   alert.Alerter
+
+	mu       sync.Mutex
+	failures int
+	paused   bool
+}
+
+func NewGuard(client messageClient, threshold int, alerter alert.Alerter) *Guard {
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Guard{
+		client:    client,
+		threshold: threshold,
+		alerter:   alerter,
+	}
+}
+
+func (g *Guard) SendMessage(ctx context.Context, requestURL string, cookie string, headers map[string]string, messageBody *sender.Body) error {
+	if g.Paused() {
+		return ErrPaused
+	}
+
+	err := g.client.SendMessage(ctx, requestURL, cookie, headers, messageBody)
+	g.observe(err)
+
+	return err
+}
+
+func (g *Guard) Retryable(err error) bool {
+	return errors.Is(err, ErrPaused) || apiclient.Classify(err) == apiclient.ClassAuth || g.client.Retryable(err)
+}
+
+// Paused сообщает, приостановлена ли отправка
+func (g *Guard) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.paused
+}
+
+// Resume снимает паузу и сбрасывает счетчик ошибок, например после замены curl-файла
+func (g *Guard) Resume() {
+	g.mu.Lock()
+	wasPaused := g.paused
+	g.paused = false
+	g.failures = 0
+	g.mu.Unlock()
+
+	if !wasPaused {
+		return
+	}
+
+	log.Println("Sending resumed with new credentials")
+	g.alert("Отправка возобновлена", "Получен новый curl-файл, отправка сообщений возобновлена")
+}
+
+func (g *Guard) observe(err error) {
+	if err != nil && apiclient.Classify(err) != apiclient.ClassAuth {
+		// сетевые и серверные ошибки ничего не говорят о сессии
+		return
+	}
+
+	g.mu.Lock()
+	if err == nil {
+		g.failures = 0
+		g.mu.Unlock()
+		return
+	}
+
+	g.failures++
+	failures := g.failures
+	trip := failures >= g.threshold && !g.paused
+	if trip {
+		g.paused = true
+	}
+	g.mu.Unlock()
+
+	log.Printf("Auth failure %d/%d: %v", failures, g.threshold, err)
+
+	if trip {
+		log.Printf("Sending paused after %d auth failures, waiting for a new curl file", failures)
+		g.alert("Сессия бота истекла",
+			fmt.Sprintf("API %d раз подряд отверг авторизацию (последняя ошибка: %v). Отправка приостановлена, "+
+				"неотправленные сообщения ждут в outbox. Положите свежий curl-файл, чтобы возобновить отправку", failures, err))
+	}
+}
+
+func (g *Guard) alert(subject, text string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+		defer cancel()
+
+		if err := g.alerter.Alert(ctx, subject, text); err != nil {
+			log.Println("Failed to send alert:", err)
+		}
+	}()
+}
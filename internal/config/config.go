@@ -10,16 +10,42 @@ type (
 const (
 	// CurlFile File location with copied from DevTools cURL request for sending message
 	CurlFile configKey = "values.curl_file"
-	// CronExpr cron expr
-	CronExpr realtimeConfigKey = "realtime_config.cron_expr"
-	// MessageText Текст сообщения
-	MessageText realtimeConfigKey = "realtime_config.message_text"
-	// Markup Задает форматирование????
-	Markup realtimeConfigKey = "realtime_config.markup"
-	// ChatId Определяет, кому слать сообщение
-	ChatId realtimeConfigKey = "realtime_config.chat_id"
-	// SendEnabled Включает или выключает отправку сообщений
-	SendEnabled realtimeConfigKey = "realtime_config.send_enabled"
+	// Timezone Часовой пояс по умолчанию для расписаний и шаблонов (например, Europe/Moscow)
+	Timezone configKey = "values.timezone"
+	// CalendarFile Производственный календарь (YAML или ICS) с праздниками и перенесенными рабочими днями
+	CalendarFile configKey = "values.calendar_file"
+	// StateFile Файл с состоянием задач (последние запуски), переживающим рестарт
+	StateFile configKey = "values.state_file"
+	// CatchUpWindow Насколько давний пропущенный запуск еще догоняется после рестарта
+	CatchUpWindow configKey = "values.catch_up_window"
+	// ShutdownTimeout Сколько ждать выполняющиеся задачи при остановке, прежде чем прервать их
+	ShutdownTimeout configKey = "values.shutdown_timeout"
+	// InstanceLockFile Файл аренды в общем томе: задачи планирует только экземпляр, владеющий арендой
+	InstanceLockFile configKey = "values.instance_lock_file"
+	// InstanceLeaseTTL Срок аренды, после которого резервный экземпляр перехватывает работу
+	InstanceLeaseTTL configKey = "values.instance_lease_ttl"
+	// SentLedgerFile Журнал уже отправленных сообщений, защищает от повторной отправки
+	SentLedgerFile configKey = "values.sent_ledger_file"
+	// MessagePoolFile Позиции ротации вариантов сообщений, чтобы ротация продолжалась после рестарта
+	MessagePoolFile configKey = "values.message_pool_file"
+	// CookieJarFile Cookie сессии, обновленные сервером через Set-Cookie, чтобы они пережили рестарт
+	CookieJarFile configKey = "values.cookie_jar_file"
+	// OutboxFile Очередь сообщений, не отправленных из-за временной ошибки API
+	OutboxFile configKey = "values.outbox_file"
+	// OutboxStaleAfter Через сколько после постановки в очередь сообщение считается устаревшим
+	OutboxStaleAfter configKey = "values.outbox_stale_after"
+
+	// Alert Резервный канал оповещений: type (webhook, smtp, file, log) и его настройки
+	Alert configKey = "values.alert"
+	// AuthFailureThreshold После скольких ошибок авторизации подряд отправка приостанавливается
+	AuthFailureThreshold configKey = "values.auth_failure_threshold"
+	// CredentialWarnBefore За сколько до истечения cookie предупреждать, список длительностей (например, [72h, 24h])
+	CredentialWarnBefore configKey = "values.credential_warn_before"
+	// CredentialMaxAge Известное время жизни сессии от момента сохранения curl-файла, если в cookie нет срока
+	CredentialMaxAge configKey = "values.credential_max_age"
+
+	// RetryPolicy Политика повторов отправки: max_attempts, base_backoff, max_backoff, jitter, retry_on
+	RetryPolicy realtimeConfigKey = "realtime_config.retry_policy"
 )
 
 func GetValue[T configKey | realtimeConfigKey](key T) (realtimeconfig.Value, error) {
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/realtimeconfig"
+)
+
+// JobsSection Секция со списком напоминаний
+const JobsSection = "jobs"
+
+// Job Описание одного напоминания из секции jobs
+type Job struct {
+	// Name Уникальное имя задачи
+	Name string `yaml:"name"`
+	// CronExpr Расписание в формате cron с секундами
+	CronExpr string `yaml:"cron_expr"`
+	// At Время разового запуска вместо cron_expr
+	At time.Time `yaml:"at"`
+	// Deadline Дедлайн, перед которым шлется серия напоминаний, вместо cron_expr
+	Deadline time.Time `yaml:"deadline"`
+	// RemindBefore За сколько до дедлайна напоминать, например [168h, 24h, 1h]
+	RemindBefore []time.Duration `yaml:"remind_before"`
+	// ChatID Определяет, кому слать сообщение
+	ChatID int64 `yaml:"chat_id"`
+	// MessageText Шаблон текста сообщения
+	MessageText string `yaml:"message_text"`
+	// Messages Варианты шаблона вместо message_text, из которых каждый раз выбирается один
+	Messages []string `yaml:"messages"`
+	// MessageStrategy Как выбирать вариант: round_robin, random или shuffle
+	MessageStrategy string `yaml:"message_strategy"`
+	// Markup Задает форматирование
+	Markup []any `yaml:"markup"`
+	// Vars Произвольные переменные задачи, доступные в шаблоне как .Vars
+	Vars map[string]any `yaml:"vars"`
+	// SendEnabled Включает или выключает отправку сообщений
+	SendEnabled bool `yaml:"send_enabled"`
+	// Timezone Часовой пояс задачи, по умолчанию values.timezone
+	Timezone string `yaml:"timezone"`
+	// BusinessDaysOnly Не слать в выходные и праздники по производственному календарю
+	BusinessDaysOnly bool `yaml:"business_days_only"`
+	// CatchUp Что делать с запуском, пропущенным во время простоя: skip или run_once
+	CatchUp string `yaml:"catch_up"`
+	// CatchUpWindow Окно догонялки для этой задачи, по умолчанию values.catch_up_window
+	CatchUpWindow time.Duration `yaml:"catch_up_window"`
+	// Overlap Что делать, если предыдущий запуск еще идет: skip, delay или allow
+	Overlap string `yaml:"overlap"`
+	// Timeout Максимальная длительность одного запуска
+	Timeout time.Duration `yaml:"timeout"`
+	// PausedUntil Не слать до указанного момента, расписание при этом сохраняется
+	PausedUntil time.Time `yaml:"paused_until"`
+	// Jitter Случайный сдвиг после времени по расписанию, от 0 до указанного значения
+	Jitter time.Duration `yaml:"jitter"`
+	// SendWindow Разрешенное окно отправки, например 09:00-19:00
+	SendWindow string `yaml:"send_window"`
+	// WindowPolicy Что делать с запуском вне окна: defer (перенести на начало окна) или drop
+	WindowPolicy string `yaml:"window_policy"`
+}
+
+// Location возвращает часовой пояс задачи или nil, если он не задан
+func (j Job) Location() (*time.Location, error) {
+	if j.Timezone == "" {
+		return nil, nil
+	}
+
+	return time.LoadLocation(j.Timezone)
+}
+
+func GetJobs() ([]Job, error) {
+	raw, err := realtimeconfig.GetSection(JobsSection)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJobs(raw)
+}
+
+// WatchJobs вызывает callback с полным новым списком задач при каждом изменении секции jobs
+func WatchJobs(callback func(jobs []Job)) {
+	realtimeconfig.WatchSection(JobsSection, func(newValue, _ realtimeconfig.Value) {
+		jobs, err := decodeJobs(newValue)
+		if err != nil {
+			log.Println("Failed to parse jobs in live config:", err)
+			return
+		}
+
+		callback(jobs)
+	})
+}
+
+func decodeJobs(raw realtimeconfig.Value) ([]Job, error) {
+	var jobs []Job
+	if err := raw.Decode(&jobs); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(jobs))
+	for _, job := range jobs {
+		if job.Name == "" {
+			return nil, errors.New("job name is empty")
+		}
+		if _, ok := seen[job.Name]; ok {
+			return nil, fmt.Errorf("duplicate job name: %s", job.Name)
+		}
+		seen[job.Name] = struct{}{}
+
+		triggers := 0
+		if job.CronExpr != "" {
+			triggers++
+		}
+		if !job.At.IsZero() {
+			triggers++
+		}
+		if !job.Deadline.IsZero() {
+			triggers++
+			if len(job.RemindBefore) == 0 {
+				return nil, fmt.Errorf("job %s: remind_before is required with deadline", job.Name)
+			}
+		}
+		if triggers != 1 {
+			return nil, fmt.Errorf("job %s: exactly one of cron_expr, at or deadline must be set", job.Name)
+		}
+		if job.MessageText != "" && len(job.Messages) > 0 {
+			return nil, fmt.Errorf("job %s: only one of message_text or messages can be set", job.Name)
+		}
+		if _, err := job.Location(); err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,154 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Credential Cookie, у которой удалось определить срок действия
+type Credential struct {
+	// Name Имя cookie
+	Name      string
+	ExpiresAt time.Time
+	// Source Откуда взят срок: jwt exp, поле с датой в значении или max_age из конфига
+	Source string
+}
+
+// expiryFields Поля, в которых значение cookie в виде JSON обычно хранит срок действия
+var expiryFields = []string{"exp", "expires", "expires_at", "expiresAt", "expiry", "expiration"}
+
+// Inspect разбирает строку Cookie ("a=1; b=2") и возвращает cookie с известным сроком действия,
+// отсортированные от самой ранней. Срок берется из exp в JWT или из полей срока в значении-JSON
+func Inspect(cookie string) []Credential {
+	var creds []Credential
+
+	for _, part := range strings.Split(cookie, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		if expiresAt, source, ok := valueExpiry(value); ok {
+			creds = append(creds, Credential{Name: name, ExpiresAt: expiresAt, Source: source})
+		}
+	}
+
+	slices.SortFunc(creds, byExpiry)
+
+	return creds
+}
+
+// ExpiresAt Самый ранний срок среди cookie, нулевой, если ни один срок не известен
+func ExpiresAt(creds []Credential) time.Time {
+	if len(creds) == 0 {
+		return time.Time{}
+	}
+	return creds[0].ExpiresAt
+}
+
+func byExpiry(a, b Credential) int {
+	return a.ExpiresAt.Compare(b.ExpiresAt)
+}
+
+func valueExpiry(value string) (time.Time, string, bool) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		value = unescaped
+	}
+	value = strings.TrimPrefix(value, "Bearer ")
+
+	if claims, ok := jwtClaims(value); ok {
+		if exp, ok := claimTime(claims["exp"]); ok {
+			return exp, "jwt exp", true
+		}
+	}
+
+	fields, ok := jsonValue(value)
+	if !ok {
+		return time.Time{}, "", false
+	}
+
+	for _, key := range expiryFields {
+		if t, ok := claimTime(fields[key]); ok {
+			return t, key + " field", true
+		}
+	}
+
+	return time.Time{}, "", false
+}
+
+// jwtClaims декодирует полезную нагрузку JWT без проверки подписи: нужен только срок действия
+func jwtClaims(token string) (map[string]any, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, false
+	}
+
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// jsonValue пробует прочитать значение cookie как JSON, в том числе закодированный в base64
+func jsonValue(value string) (map[string]any, bool) {
+	var fields map[string]any
+	if json.Unmarshal([]byte(value), &fields) == nil {
+		return fields, true
+	}
+
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := enc.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		if json.Unmarshal(decoded, &fields) == nil {
+			return fields, true
+		}
+	}
+
+	return nil, false
+}
+
+// claimTime читает срок в секундах или миллисекундах Unix либо в RFC3339
+func claimTime(v any) (time.Time, bool) {
+	var seconds float64
+
+	switch val := v.(type) {
+	case float64:
+		seconds = val
+	case string:
+		if t, err := time.Parse(time.RFC3339, val); err == nil {
+			return t, true
+		}
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		seconds = n
+	default:
+		return time.Time{}, false
+	}
+
+	if seconds <= 0 {
+		return time.Time{}, false
+	}
+	// значения больше 1e11 - это уже миллисекунды
+	if seconds > 1e11 {
+		seconds /= 1000
+	}
+
+	return time.Unix(int64(seconds), 0), true
+}
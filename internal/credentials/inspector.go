@@ -0,0 +1,175 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/alert"
+)
+
+const (
+	// checkInterval Как часто сверять срок действия с порогами предупреждений
+	checkInterval = time.Minute
+	alertTimeout  = 30 * time.Second
+)
+
+// CookieSource Возвращает текущую строку Cookie сессии
+type CookieSource func() (string, error)
+
+// Inspector Следит за сроком действия текущих cookie сессии и заранее предупреждает
+// через канал оповещений, за сколько до истечения - задается порогами warnBefore
+type Inspector struct {
+	source     CookieSource
+	alerter    alert.Alerter
+	warnBefore []time.Duration
+	// maxAge Известное время жизни сессии, отсчитывается от момента получения cookie
+	maxAge time.Duration
+
+	mu        sync.Mutex
+	creds     []Credential
+	expiresAt time.Time
+	// warned Пороги, о которых уже предупредили для текущего срока; 0 - предупреждение об истечении
+	warned map[time.Duration]bool
+}
+
+func NewInspector(source CookieSource, alerter alert.Alerter, warnBefore []time.Duration, maxAge time.Duration) *Inspector {
+	warnBefore = slices.Clone(warnBefore)
+	slices.Sort(warnBefore)
+
+	return &Inspector{
+		source:     source,
+		alerter:    alerter,
+		warnBefore: warnBefore,
+		maxAge:     maxAge,
+		warned:     make(map[time.Duration]bool),
+	}
+}
+
+// Refresh заново разбирает cookie, например после замены curl-файла или обновления сессии
+// сервером через Set-Cookie. issuedAt - когда учетные данные получены, от него считается max_age. Если срок изменился, предупреждения начинаются заново
+func (i *Inspector) Refresh(issuedAt time.Time) {
+	cookie, err := i.source()
+	if err != nil {
+		log.Println("Failed to inspect credentials:", err)
+		return
+	}
+
+	creds := Inspect(cookie)
+	if i.maxAge > 0 && !issuedAt.IsZero() {
+		creds = append(creds, Credential{Name: "session", ExpiresAt: issuedAt.Add(i.maxAge), Source: "max_age"})
+		slices.SortFunc(creds, byExpiry)
+	}
+	expiresAt := ExpiresAt(creds)
+
+	i.mu.Lock()
+	changed := !expiresAt.Equal(i.expiresAt)
+	i.creds = creds
+	i.expiresAt = expiresAt
+	if changed {
+		i.warned = make(map[time.Duration]bool)
+	}
+	i.mu.Unlock()
+
+	if expiresAt.IsZero() {
+		log.Println("Credentials expiry is unknown: no JWT exp or expiry fields in cookies")
+		return
+	}
+
+	for _, c := range creds {
+		log.Printf("Credential %s expires at %s (%s)", c.Name, c.ExpiresAt.Format(time.RFC3339), c.Source)
+	}
+	log.Printf("Credentials expire at %s", expiresAt.Format(time.RFC3339))
+}
+
+// ExpiresAt Когда истекают учетные данные: самый ранний из известных сроков, нулевой, если неизвестен
+func (i *Inspector) ExpiresAt() time.Time {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.expiresAt
+}
+
+// Credentials Все cookie с известным сроком действия
+func (i *Inspector) Credentials() []Credential {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return slices.Clone(i.creds)
+}
+
+// Run проверяет пороги предупреждений, пока не отменен ctx
+func (i *Inspector) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		i.check(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check предупреждает о ближайшем пройденном пороге. Более дальние пройденные пороги
+// считаются уже неактуальными, чтобы после рестарта не прислать сразу несколько оповещений
+func (i *Inspector) check(now time.Time) {
+	i.mu.Lock()
+	expiresAt := i.expiresAt
+	if expiresAt.IsZero() {
+		i.mu.Unlock()
+		return
+	}
+
+	left := expiresAt.Sub(now)
+
+	var subject, text string
+	switch {
+	case left <= 0:
+		if !i.warned[0] {
+			subject = "Учетные данные истекли"
+			text = fmt.Sprintf("Cookie из curl-файла истекли %s. Положите свежий curl-файл", expiresAt.Format(time.RFC3339))
+		}
+		i.warned[0] = true
+		for _, lead := range i.warnBefore {
+			i.warned[lead] = true
+		}
+	default:
+		for _, lead := range i.warnBefore {
+			if left > lead {
+				continue
+			}
+			if !i.warned[lead] {
+				subject = "Учетные данные скоро истекут"
+				text = fmt.Sprintf("Cookie из curl-файла истекают %s, осталось %s. Обновите curl-файл заранее",
+					expiresAt.Format(time.RFC3339), left.Round(time.Minute))
+			}
+			for _, l := range i.warnBefore {
+				if l >= lead {
+					i.warned[l] = true
+				}
+			}
+			break
+		}
+	}
+	i.mu.Unlock()
+
+	if subject == "" {
+		return
+	}
+
+	log.Printf("%s: %s", subject, text)
+
+	ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+	defer cancel()
+
+	if err := i.alerter.Alert(ctx, subject, text); err != nil {
+		log.Println("Failed to send alert:", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 )
@@ -37,19 +38,37 @@ type Message struct {
 }
 
 type Parser struct {
+	mu      sync.RWMutex
 	curlCMD string
 }
 
 func NewParser(curl string) *Parser {
+	p := &Parser{}
+	p.Reload(curl)
+
+	return p
+}
+
+// Reload подменяет cURL-команду, например после замены curl-файла свежей копией из DevTools
+func (p *Parser) Reload(curl string) {
 	// убираем переносы строк с "\"
 	curl = strings.ReplaceAll(curl, "\\\n", " ")
 	curl = strings.ReplaceAll(curl, "\n", " ")
 
-	return &Parser{curlCMD: curl}
+	p.mu.Lock()
+	p.curlCMD = curl
+	p.mu.Unlock()
+}
+
+func (p *Parser) command() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.curlCMD
 }
 
 func (p *Parser) GetRequestURL() (string, error) {
-	m := urlRE.FindStringSubmatch(p.curlCMD)
+	m := urlRE.FindStringSubmatch(p.command())
 	if len(m) < 2 {
 		return "", fmt.Errorf("url not found in curl command")
 	}
@@ -57,7 +76,7 @@ func (p *Parser) GetRequestURL() (string, error) {
 }
 
 func (p *Parser) GetHeaders() (map[string]string, error) {
-	matches := headersRE.FindAllStringSubmatch(p.curlCMD, -1)
+	matches := headersRE.FindAllStringSubmatch(p.command(), -1)
 	if len(matches) == 0 {
 		return nil, fmt.Errorf("headers not found in curl command")
 	}
@@ -87,7 +106,7 @@ func (p *Parser) GetHeaders() (map[string]string, error) {
 }
 
 func (p *Parser) GetCookie() (string, error) {
-	m := cookieRE.FindStringSubmatch(p.curlCMD)
+	m := cookieRE.FindStringSubmatch(p.command())
 	if len(m) == 0 {
 		return "", fmt.Errorf("cookie not found in curl command")
 	}
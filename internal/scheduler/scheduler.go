@@ -0,0 +1,292 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/config"
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/cron"
+)
+
+type taskManager interface {
+	AddTask(ctx context.Context, spec string, task cron.Task, opts ...cron.TaskOption) error
+	AddOneShot(ctx context.Context, at time.Time, task cron.Task, opts ...cron.TaskOption) error
+	AddDeadline(ctx context.Context, deadline time.Time, offsets []time.Duration, task cron.Task, opts ...cron.TaskOption) error
+	RemoveTask(name string) error
+	Reschedule(name string, spec string) (oldNext time.Time, newNext time.Time, err error)
+	Pause(name string, until time.Time) error
+	Resume(name string) error
+}
+
+// TaskFactory строит задачу по описанию из секции jobs. Ошибка означает, что описание не годится,
+// и задача с таким описанием не будет ни добавлена, ни заменена
+type TaskFactory func(job config.Job) (cron.Task, error)
+
+// updatableTask Задача, которая умеет атомарно принять новые настройки сообщения без пересоздания.
+// PrepareUpdate только проверяет настройки, применяются они вызовом возвращенной функции.
+// При ошибке задача продолжает работать с прежними настройками
+type updatableTask interface {
+	PrepareUpdate(job config.Job) (func(), error)
+}
+
+// Scheduler держит задачи cron.Manager в соответствии с секцией jobs
+type Scheduler struct {
+	ctx     context.Context
+	manager taskManager
+	newTask TaskFactory
+
+	mu    sync.Mutex
+	jobs  map[string]config.Job
+	tasks map[string]cron.Task
+}
+
+func NewScheduler(ctx context.Context, manager taskManager, newTask TaskFactory) *Scheduler {
+	return &Scheduler{
+		ctx:     ctx,
+		manager: manager,
+		newTask: newTask,
+		jobs:    make(map[string]config.Job),
+		tasks:   make(map[string]cron.Task),
+	}
+}
+
+// Apply приводит набор задач к переданному списку: добавляет новые, удаляет пропавшие
+// и пересоздает только те, чье описание изменилось
+func (s *Scheduler) Apply(jobs []config.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+
+	wanted := make(map[string]config.Job, len(jobs))
+	for _, job := range jobs {
+		wanted[job.Name] = job
+	}
+
+	for name := range s.jobs {
+		if _, ok := wanted[name]; ok {
+			continue
+		}
+
+		if err := s.manager.RemoveTask(name); err != nil && !errors.Is(err, cron.ErrSpecifiedTaskNotFound) {
+			errs = append(errs, fmt.Errorf("remove job %s: %w", name, err))
+			continue
+		}
+		delete(s.jobs, name)
+		delete(s.tasks, name)
+	}
+
+	for _, job := range jobs {
+		if err := s.applyJob(job); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyJob добавляет задачу или обновляет существующую: смена только расписания делается
+// атомарным Reschedule, смена паузы - через Pause/Resume, смена текста, чата и прочих настроек
+// сообщения - одним снимком через Update, остальное пересоздает задачу.
+// Новые настройки и опции собираются и проверяются до того, как задача будет изменена,
+// поэтому при ошибке она целиком остается с прежним описанием
+func (s *Scheduler) applyJob(job config.Job) error {
+	current, exists := s.jobs[job.Name]
+	if exists && reflect.DeepEqual(current, job) {
+		return nil
+	}
+
+	reschedule := exists && current.CronExpr != job.CronExpr && current.CronExpr != "" && job.CronExpr != ""
+	rescheduled := current
+	if reschedule {
+		rescheduled.CronExpr = job.CronExpr
+	}
+
+	task, hasTask := s.tasks[job.Name]
+	updatable, canUpdate := task.(updatableTask)
+	recreated := false
+
+	if !exists || !hasTask || !sameTask(rescheduled, job) || (!canUpdate && !sameSettings(current, job)) {
+		opts, err := taskOptions(job)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+
+		task, err := s.newTask(job)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+
+		if exists {
+			if err := s.manager.RemoveTask(job.Name); err != nil && !errors.Is(err, cron.ErrSpecifiedTaskNotFound) {
+				return fmt.Errorf("remove job %s: %w", job.Name, err)
+			}
+			delete(s.jobs, job.Name)
+			delete(s.tasks, job.Name)
+		}
+
+		if err := s.addTask(job, task, opts); err != nil {
+			return fmt.Errorf("add job %s: %w", job.Name, err)
+		}
+		s.tasks[job.Name] = task
+		recreated = true
+
+		if exists {
+			log.Printf("Job %s updated", job.Name)
+		}
+	} else {
+		var applySettings func()
+		if !sameSettings(current, job) {
+			apply, err := updatable.PrepareUpdate(job)
+			if err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+			applySettings = apply
+		}
+
+		if reschedule {
+			if _, _, err := s.manager.Reschedule(job.Name, job.CronExpr); err != nil {
+				return fmt.Errorf("reschedule job %s: %w", job.Name, err)
+			}
+		}
+
+		if applySettings != nil {
+			applySettings()
+		}
+	}
+
+	// до паузы запоминается уже примененное описание, чтобы ошибка паузы не заставила
+	// пересоздать задачу или сменить расписание повторно
+	applied := job
+	applied.PausedUntil = current.PausedUntil
+	if recreated {
+		applied.PausedUntil = time.Time{}
+	}
+	s.jobs[job.Name] = applied
+
+	// Пауза из конфига применяется только при ее изменении, чтобы не перетирать паузу,
+	// выставленную через Manager.Pause и сохраненную в состоянии. Пересозданной задаче
+	// пауза ставится заново: у серии дедлайна с новыми отступами и напоминания новые.
+	// ErrSpecifiedTaskNotFound означает, что задаче уже нечего запускать, например
+	// все напоминания серии дедлайна отработали
+	if !current.PausedUntil.Equal(job.PausedUntil) || (recreated && !job.PausedUntil.IsZero()) {
+		var err error
+		if job.PausedUntil.IsZero() {
+			err = s.manager.Resume(job.Name)
+		} else {
+			err = s.manager.Pause(job.Name, job.PausedUntil)
+		}
+		if err != nil && !errors.Is(err, cron.ErrSpecifiedTaskNotFound) {
+			return fmt.Errorf("pause job %s: %w", job.Name, err)
+		}
+	}
+
+	s.jobs[job.Name] = job
+
+	return nil
+}
+
+func (s *Scheduler) addTask(job config.Job, task cron.Task, opts []cron.TaskOption) error {
+	if !job.Deadline.IsZero() {
+		return s.manager.AddDeadline(s.ctx, job.Deadline, job.RemindBefore, task, opts...)
+	}
+
+	if !job.At.IsZero() {
+		return s.manager.AddOneShot(s.ctx, job.At, task, opts...)
+	}
+
+	return s.manager.AddTask(s.ctx, job.CronExpr, task, opts...)
+}
+
+// sameTask сравнивает описания без полей, которые меняются без пересоздания задачи
+func sameTask(a, b config.Job) bool {
+	a, b = withoutSettings(a), withoutSettings(b)
+	a.PausedUntil, b.PausedUntil = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+// sameSettings сравнивает только настройки сообщения
+func sameSettings(a, b config.Job) bool {
+	return reflect.DeepEqual(settingsOf(a), settingsOf(b))
+}
+
+// withoutSettings обнуляет настройки сообщения, которые применяются через Update
+func withoutSettings(job config.Job) config.Job {
+	job.MessageText = ""
+	job.Messages = nil
+	job.MessageStrategy = ""
+	job.Markup = nil
+	job.Vars = nil
+	job.ChatID = 0
+	job.SendEnabled = false
+	return job
+}
+
+func settingsOf(job config.Job) config.Job {
+	return config.Job{
+		MessageText:     job.MessageText,
+		Messages:        job.Messages,
+		MessageStrategy: job.MessageStrategy,
+		Markup:          job.Markup,
+		Vars:            job.Vars,
+		ChatID:          job.ChatID,
+		SendEnabled:     job.SendEnabled,
+	}
+}
+
+func taskOptions(job config.Job) ([]cron.TaskOption, error) {
+	var opts []cron.TaskOption
+
+	loc, err := job.Location()
+	if err != nil {
+		return nil, err
+	}
+	if loc != nil {
+		opts = append(opts, cron.WithTaskLocation(loc))
+	}
+
+	if job.BusinessDaysOnly {
+		opts = append(opts, cron.WithBusinessDaysOnly())
+	}
+
+	catchUp, err := cron.ParseCatchUpPolicy(job.CatchUp)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, cron.WithCatchUp(catchUp, job.CatchUpWindow))
+
+	overlap, err := cron.ParseOverlapPolicy(job.Overlap)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, cron.WithOverlapPolicy(overlap))
+
+	if job.Timeout > 0 {
+		opts = append(opts, cron.WithTimeout(job.Timeout))
+	}
+
+	if job.Jitter > 0 {
+		opts = append(opts, cron.WithJitter(job.Jitter))
+	}
+
+	if job.SendWindow != "" {
+		window, err := cron.ParseSendWindow(job.SendWindow)
+		if err != nil {
+			return nil, err
+		}
+
+		windowPolicy, err := cron.ParseWindowPolicy(job.WindowPolicy)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, cron.WithSendWindow(window, windowPolicy))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,233 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/config"
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/cron"
+)
+
+// fakeManager Записывает вызовы Scheduler, расписание "bad" не разбирается
+type fakeManager struct {
+	calls []string
+	specs map[string]string
+}
+
+func newFakeManager() *fakeManager {
+	return &fakeManager{specs: make(map[string]string)}
+}
+
+func (m *fakeManager) AddTask(_ context.Context, spec string, task cron.Task, _ ...cron.TaskOption) error {
+	m.calls = append(m.calls, "add "+task.Name())
+	m.specs[task.Name()] = spec
+	return nil
+}
+
+func (m *fakeManager) AddOneShot(_ context.Context, _ time.Time, task cron.Task, _ ...cron.TaskOption) error {
+	m.calls = append(m.calls, "add "+task.Name())
+	return nil
+}
+
+func (m *fakeManager) AddDeadline(_ context.Context, _ time.Time, _ []time.Duration, task cron.Task, _ ...cron.TaskOption) error {
+	m.calls = append(m.calls, "add "+task.Name())
+	return nil
+}
+
+func (m *fakeManager) RemoveTask(name string) error {
+	m.calls = append(m.calls, "remove "+name)
+	delete(m.specs, name)
+	return nil
+}
+
+func (m *fakeManager) Reschedule(name string, spec string) (time.Time, time.Time, error) {
+	if spec == "bad" {
+		return time.Time{}, time.Time{}, errors.New("bad spec")
+	}
+	m.calls = append(m.calls, "reschedule "+name)
+	m.specs[name] = spec
+	return time.Time{}, time.Time{}, nil
+}
+
+func (m *fakeManager) Pause(name string, _ time.Time) error {
+	m.calls = append(m.calls, "pause "+name)
+	return nil
+}
+
+func (m *fakeManager) Resume(name string) error {
+	m.calls = append(m.calls, "resume "+name)
+	return nil
+}
+
+// fakeTask Задача с обновляемым текстом, текст "{{" не разбирается
+type fakeTask struct {
+	name string
+	text string
+}
+
+func (t *fakeTask) Name() string {
+	return t.name
+}
+
+func (t *fakeTask) Work(context.Context) error {
+	return nil
+}
+
+func (t *fakeTask) PrepareUpdate(job config.Job) (func(), error) {
+	if job.MessageText == "{{" {
+		return nil, errors.New("bad template")
+	}
+	return func() { t.text = job.MessageText }, nil
+}
+
+func newFakeTask(job config.Job) (cron.Task, error) {
+	if job.MessageText == "{{" {
+		return nil, errors.New("bad template")
+	}
+	return &fakeTask{name: job.Name, text: job.MessageText}, nil
+}
+
+func reminder(spec, text string) config.Job {
+	return config.Job{Name: "reminder", CronExpr: spec, MessageText: text, SendEnabled: true}
+}
+
+func TestSchedulerApply(t *testing.T) {
+	base := reminder("0 0 9 * * *", "hello")
+
+	tests := []struct {
+		name      string
+		next      []config.Job
+		wantErr   bool
+		wantCalls []string
+		wantSpec  string
+		wantText  string
+		// wantSame Задача не пересоздавалась
+		wantSame bool
+	}{
+		{
+			name:     "unchanged",
+			next:     []config.Job{base},
+			wantSpec: base.CronExpr, wantText: "hello", wantSame: true,
+		},
+		{
+			name:      "schedule rescheduled in place",
+			next:      []config.Job{reminder("0 30 18 * * *", "hello")},
+			wantCalls: []string{"reschedule reminder"},
+			wantSpec:  "0 30 18 * * *", wantText: "hello", wantSame: true,
+		},
+		{
+			name:     "settings updated in place",
+			next:     []config.Job{reminder("0 0 9 * * *", "bye")},
+			wantSpec: base.CronExpr, wantText: "bye", wantSame: true,
+		},
+		{
+			name:      "schedule and settings updated in place",
+			next:      []config.Job{reminder("0 30 18 * * *", "bye")},
+			wantCalls: []string{"reschedule reminder"},
+			wantSpec:  "0 30 18 * * *", wantText: "bye", wantSame: true,
+		},
+		{
+			name:    "bad settings keep old schedule",
+			next:    []config.Job{reminder("0 30 18 * * *", "{{")},
+			wantErr: true, wantSpec: base.CronExpr, wantText: "hello", wantSame: true,
+		},
+		{
+			name:    "bad schedule keeps old settings",
+			next:    []config.Job{reminder("bad", "bye")},
+			wantErr: true, wantSpec: base.CronExpr, wantText: "hello", wantSame: true,
+		},
+		{
+			name: "bad options keep old task",
+			next: func() []config.Job {
+				job := reminder("0 30 18 * * *", "bye")
+				job.Overlap = "sometimes"
+				return []config.Job{job}
+			}(),
+			wantErr: true, wantSpec: base.CronExpr, wantText: "hello", wantSame: true,
+		},
+		{
+			name: "other fields recreate task",
+			next: func() []config.Job {
+				job := reminder("0 30 18 * * *", "bye")
+				job.Timeout = time.Minute
+				return []config.Job{job}
+			}(),
+			wantCalls: []string{"remove reminder", "add reminder"},
+			wantSpec:  "0 30 18 * * *", wantText: "bye",
+		},
+		{
+			name:      "missing job removed",
+			next:      nil,
+			wantCalls: []string{"remove reminder"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newFakeManager()
+			s := NewScheduler(context.Background(), manager, newFakeTask)
+			if err := s.Apply([]config.Job{base}); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(manager.calls, []string{"add reminder"}) {
+				t.Fatalf("initial Apply() calls = %v, want add", manager.calls)
+			}
+			before := s.tasks["reminder"]
+			manager.calls = nil
+
+			err := s.Apply(tt.next)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(manager.calls, tt.wantCalls) {
+				t.Errorf("Apply() calls = %v, want %v", manager.calls, tt.wantCalls)
+			}
+			if spec := manager.specs["reminder"]; spec != tt.wantSpec {
+				t.Errorf("spec = %q, want %q", spec, tt.wantSpec)
+			}
+
+			task, _ := s.tasks["reminder"].(*fakeTask)
+			if tt.wantText == "" {
+				if task != nil {
+					t.Errorf("task kept after removal")
+				}
+				return
+			}
+			if task == nil {
+				t.Fatal("task missing after Apply()")
+			}
+			if task.text != tt.wantText {
+				t.Errorf("task text = %q, want %q", task.text, tt.wantText)
+			}
+			if same := cron.Task(task) == before; same != tt.wantSame {
+				t.Errorf("task kept = %v, want %v", same, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestSchedulerRetriesFailedUpdate(t *testing.T) {
+	manager := newFakeManager()
+	s := NewScheduler(context.Background(), manager, newFakeTask)
+	if err := s.Apply([]config.Job{reminder("0 0 9 * * *", "hello")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Apply([]config.Job{reminder("0 30 18 * * *", "{{")}); err == nil {
+		t.Fatal("Apply() with bad template error = nil")
+	}
+
+	// исправленное описание применяется целиком, как будто неудачной попытки не было
+	if err := s.Apply([]config.Job{reminder("0 30 18 * * *", "bye")}); err != nil {
+		t.Fatal(err)
+	}
+	if spec := manager.specs["reminder"]; spec != "0 30 18 * * *" {
+		t.Errorf("spec = %q, want rescheduled", spec)
+	}
+	if text := s.tasks["reminder"].(*fakeTask).text; text != "bye" {
+		t.Errorf("task text = %q, want bye", text)
+	}
+}
@@ -0,0 +1,279 @@
+package sender
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	ruMonths = [...]string{"январь", "февраль", "март", "апрель", "май", "июнь",
+		"июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь"}
+	ruMonthsGenitive = [...]string{"января", "февраля", "марта", "апреля", "мая", "июня",
+		"июля", "августа", "сентября", "октября", "ноября", "декабря"}
+	ruMonthsShort = [...]string{"янв", "фев", "мар", "апр", "мая", "июн",
+		"июл", "авг", "сен", "окт", "ноя", "дек"}
+	ruWeekdays      = [...]string{"воскресенье", "понедельник", "вторник", "среда", "четверг", "пятница", "суббота"}
+	ruWeekdaysShort = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+)
+
+// TemplateFuncs Библиотека функций шаблонов сообщений. now - момент рендера, loc - часовой пояс задачи.
+// Функции, принимающие время, ставят его последним аргументом, чтобы их можно было использовать в конвейере:
+//
+//	{{.Next | in "Asia/Yekaterinburg" | ruDate "Monday, 2 January в 15:04"}}
+//	осталось {{.TimeLeft | ruDuration}}, {{plural .RunCount "раз" "раза" "раз"}}
+func TemplateFuncs(now time.Time, loc *time.Location) template.FuncMap {
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+
+	return template.FuncMap{
+		"NOW": func() string {
+			return now.Format(time.RFC3339)
+		},
+		"DEBUG": func() string {
+			info, ok := debug.ReadBuildInfo()
+
+			data := map[string]any{
+				"go_version": runtime.Version(),
+				"os":         runtime.GOOS,
+				"arch":       runtime.GOARCH,
+			}
+
+			if ok {
+				data["module_path"] = info.Main.Path
+			}
+
+			b, _ := json.Marshal(data)
+			return string(b)
+		},
+
+		// now текущее время в часовом поясе задачи
+		"now": func() time.Time {
+			return now
+		},
+		// date форматирует время по раскладке Go: {{.Next | date "Mon 15:04"}}
+		"date": func(layout string, t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(layout)
+		},
+		"ruDate":         ruDate,
+		"ruMonth":        func(t time.Time) string { return ruMonths[t.Month()-1] },
+		"ruWeekday":      func(t time.Time) string { return ruWeekdays[t.Weekday()] },
+		"ruWeekdayShort": func(t time.Time) string { return ruWeekdaysShort[t.Weekday()] },
+
+		// in переводит время в другой часовой пояс
+		"in": func(name string, t time.Time) (time.Time, error) {
+			l, err := time.LoadLocation(name)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return t.In(l), nil
+		},
+
+		"ruDuration": ruDuration,
+		// ruRelative время относительно момента рендера: "через 3 часа", "2 дня назад"
+		"ruRelative": func(t time.Time) string {
+			d := t.Sub(now)
+			switch {
+			case d.Abs() < time.Minute:
+				return "сейчас"
+			case d > 0:
+				return "через " + ruDuration(d)
+			default:
+				return ruDuration(-d) + " назад"
+			}
+		},
+		"plural": plural,
+
+		"isWeekend": isWeekend,
+		"isWeekday": func(t time.Time) bool { return !isWeekend(t) },
+
+		// add прибавляет длительность: {{.Deadline | add "-1h"}}
+		"add": func(d any, t time.Time) (time.Time, error) {
+			dur, err := toDuration(d)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return t.Add(dur), nil
+		},
+		"addDays": func(n any, t time.Time) (time.Time, error) {
+			days, err := toInt(n)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return t.AddDate(0, 0, int(days)), nil
+		},
+		"startOfDay": startOfDay,
+		// daysUntil сколько календарных дней от сегодня до t, отрицательное для прошлого
+		"daysUntil": func(t time.Time) int {
+			return int(math.Round(startOfDay(t.In(now.Location())).Sub(startOfDay(now)).Hours() / 24))
+		},
+	}
+}
+
+// ruDate форматирует время по раскладке Go, подставляя русские названия:
+// January - "ноября" (в родительном падеже, для "3 ноября"), Jan - "ноя", Monday - "понедельник", Mon - "пн"
+func ruDate(layout string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	var b strings.Builder
+	for {
+		i, token := nextRuToken(layout)
+		if i < 0 {
+			b.WriteString(t.Format(layout))
+			break
+		}
+
+		b.WriteString(t.Format(layout[:i]))
+		layout = layout[i+len(token):]
+
+		switch token {
+		case "January":
+			b.WriteString(ruMonthsGenitive[t.Month()-1])
+		case "Jan":
+			b.WriteString(ruMonthsShort[t.Month()-1])
+		case "Monday":
+			b.WriteString(ruWeekdays[t.Weekday()])
+		case "Mon":
+			b.WriteString(ruWeekdaysShort[t.Weekday()])
+		}
+	}
+
+	return b.String()
+}
+
+// nextRuToken ищет ближайшее название месяца или дня недели в раскладке, длинные формы в приоритете
+func nextRuToken(layout string) (int, string) {
+	index, token := -1, ""
+	for _, tok := range []string{"January", "Monday", "Jan", "Mon"} {
+		if i := strings.Index(layout, tok); i >= 0 && (index < 0 || i < index) {
+			index, token = i, tok
+		}
+	}
+	return index, token
+}
+
+// ruDuration длительность словами, не больше двух старших единиц: "2 дня 3 часа", "45 минут"
+func ruDuration(d time.Duration) string {
+	d = d.Abs().Round(time.Second)
+
+	units := []struct {
+		size           time.Duration
+		one, few, many string
+	}{
+		{24 * time.Hour, "день", "дня", "дней"},
+		{time.Hour, "час", "часа", "часов"},
+		{time.Minute, "минута", "минуты", "минут"},
+		{time.Second, "секунда", "секунды", "секунд"},
+	}
+
+	var parts []string
+	for _, u := range units {
+		if len(parts) == 2 {
+			break
+		}
+
+		n := int64(d / u.size)
+		if n == 0 {
+			if len(parts) > 0 {
+				break
+			}
+			continue
+		}
+		d -= time.Duration(n) * u.size
+
+		parts = append(parts, fmt.Sprintf("%d %s", n, pluralForm(n, u.one, u.few, u.many)))
+	}
+
+	if len(parts) == 0 {
+		return "0 секунд"
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// plural выбирает форму слова для числа n: {{plural 3 "день" "дня" "дней"}} - "дня"
+func plural(n any, one, few, many string) (string, error) {
+	i, err := toInt(n)
+	if err != nil {
+		return "", err
+	}
+	return pluralForm(i, one, few, many), nil
+}
+
+func pluralForm(n int64, one, few, many string) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch {
+	case n%100 >= 11 && n%100 <= 14:
+		return many
+	case n%10 == 1:
+		return one
+	case n%10 >= 2 && n%10 <= 4:
+		return few
+	default:
+		return many
+	}
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// toInt приводит целое из шаблона (константу или поле любого целого типа) к int64
+func toInt(v any) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	case float64:
+		return int64(n), nil
+	default:
+		return 0, fmt.Errorf("expected integer, got %T", v)
+	}
+}
+
+func toDuration(v any) (time.Duration, error) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, nil
+	case string:
+		return time.ParseDuration(d)
+	default:
+		return 0, fmt.Errorf("expected duration, got %T", v)
+	}
+}
@@ -0,0 +1,126 @@
+package sender
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPluralForm(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "дней"},
+		{1, "день"},
+		{2, "дня"},
+		{4, "дня"},
+		{5, "дней"},
+		{11, "дней"},
+		{12, "дней"},
+		{14, "дней"},
+		{21, "день"},
+		{22, "дня"},
+		{25, "дней"},
+		{101, "день"},
+		{111, "дней"},
+		{112, "дней"},
+		{121, "день"},
+		{-1, "день"},
+		{-22, "дня"},
+	}
+
+	for _, tt := range tests {
+		if got := pluralForm(tt.n, "день", "дня", "дней"); got != tt.want {
+			t.Errorf("pluralForm(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPluralAcceptsTemplateIntegers(t *testing.T) {
+	tests := []struct {
+		n    any
+		want string
+	}{
+		{int(21), "минута"},
+		{int64(3), "минуты"},
+		{uint8(11), "минут"},
+		{float64(2), "минуты"},
+	}
+
+	for _, tt := range tests {
+		got, err := plural(tt.n, "минута", "минуты", "минут")
+		if err != nil {
+			t.Errorf("plural(%T %v) error = %v", tt.n, tt.n, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("plural(%T %v) = %q, want %q", tt.n, tt.n, got, tt.want)
+		}
+	}
+
+	if _, err := plural("три", "минута", "минуты", "минут"); err == nil {
+		t.Error("plural(string) error = nil, want error")
+	}
+}
+
+func TestRuDate(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		layout string
+		t      time.Time
+		want   string
+	}{
+		{"2 January 2006", time.Date(2025, time.March, 3, 0, 0, 0, 0, moscow), "3 марта 2025"},
+		{"Monday, 2 January", time.Date(2025, time.May, 1, 0, 0, 0, 0, moscow), "четверг, 1 мая"},
+		{"Mon 02 Jan 15:04", time.Date(2025, time.December, 28, 9, 5, 0, 0, moscow), "вс 28 дек 09:05"},
+		{"2 January 3:04 PM", time.Date(2025, time.January, 21, 15, 30, 0, 0, moscow), "21 января 3:30 PM"},
+		{"02.01.2006", time.Date(2025, time.August, 9, 0, 0, 0, 0, moscow), "09.08.2025"},
+		{"2 January", time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := ruDate(tt.layout, tt.t); got != tt.want {
+			t.Errorf("ruDate(%q, %s) = %q, want %q", tt.layout, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestRuMonthAndWeekday(t *testing.T) {
+	funcs := TemplateFuncs(time.Now(), time.UTC)
+	ruMonth := funcs["ruMonth"].(func(time.Time) string)
+	ruWeekday := funcs["ruWeekday"].(func(time.Time) string)
+
+	for month, want := range map[time.Month]string{time.January: "январь", time.May: "май", time.December: "декабрь"} {
+		if got := ruMonth(time.Date(2025, month, 1, 0, 0, 0, 0, time.UTC)); got != want {
+			t.Errorf("ruMonth(%s) = %q, want %q", month, got, want)
+		}
+	}
+
+	// 2025-06-01 - воскресенье
+	if got := ruWeekday(time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)); got != "воскресенье" {
+		t.Errorf("ruWeekday(2025-06-01) = %q, want воскресенье", got)
+	}
+}
+
+func TestRuDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0 секунд"},
+		{time.Second, "1 секунда"},
+		{45 * time.Minute, "45 минут"},
+		{21 * time.Minute, "21 минута"},
+		{time.Hour + 30*time.Second, "1 час"},
+		{2*24*time.Hour + 3*time.Hour + 15*time.Minute, "2 дня 3 часа"},
+		{11 * 24 * time.Hour, "11 дней"},
+		{-90 * time.Second, "1 минута 30 секунд"},
+	}
+
+	for _, tt := range tests {
+		if got := ruDuration(tt.d); got != tt.want {
+			t.Errorf("ruDuration(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
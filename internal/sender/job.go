@@ -1,22 +1,17 @@
 package sender
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
-	"runtime"
-	"runtime/debug"
+	"sync/atomic"
 	"text/template"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/psevdocoder/gentleman-ping-bot/internal/config"
-	"github.com/psevdocoder/gentleman-ping-bot/pkg/realtimeconfig"
-)
-
-const (
-	SendMessageJobName = "SendMessage"
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/cron"
 )
 
 const (
@@ -46,135 +41,136 @@ type parser interface {
 
 type apiClient interface {
 	SendMessage(ctx context.Context, requestURL string, cookie string, headers map[string]string, messageBody *Body) error
+	// Retryable сообщает, временная ли ошибка отправки
+	Retryable(err error) bool
 }
 
 type SendMessageJob struct {
-	parser        parser
-	client        apiClient
-	messageTplRaw string
-	markup        []any
-	chatID        int64
-	sendEnabled   bool
+	name     string
+	parser   parser
+	client   apiClient
+	ledger   *Ledger
+	pool     *Pool
+	outbox   *Outbox
+	settings atomic.Pointer[settings]
 }
 
-func NewSendMessageJob(parser parser, client apiClient) (*SendMessageJob, error) {
+// settings Неизменяемый снимок настроек задачи. Заменяется целиком, поэтому запуск
+// никогда не видит смесь старых и новых значений из разных сохранений конфига
+type settings struct {
+	// messageTpls Шаблоны message_text или вариантов messages, разобранные и проверенные при загрузке
+	messageTpls []*template.Template
+	strategy    PoolStrategy
+	// poolHash Отпечаток списка вариантов, при его смене ротация начинается заново
+	poolHash    string
+	markup      []any
+	vars        map[string]any
+	chatID      int64
+	sendEnabled bool
+}
 
-	// --- Message template (RAW) ---
-	messageRaw, err := config.GetValue(config.MessageText)
-	if err != nil {
-		return nil, err
-	}
-	messageTplRaw, err := messageRaw.String()
+func newSettings(cfg config.Job) (*settings, error) {
+	strategy, err := ParsePoolStrategy(cfg.MessageStrategy)
 	if err != nil {
 		return nil, err
 	}
 
-	var markup []any
-	markupRaw, err := config.GetValue(config.Markup)
-	if err == nil {
-		markupStr, err := markupRaw.String()
-		if err == nil && markupStr != "" {
-			if err := json.Unmarshal([]byte(markupStr), &markup); err != nil {
-				return nil, err
-			}
+	var tpls []*template.Template
+	if len(cfg.Messages) == 0 {
+		tpl, err := parseTemplate(cfg.Name, "message_text", cfg.MessageText, cfg.Vars)
+		if err != nil {
+			return nil, err
 		}
+		tpls = append(tpls, tpl)
 	}
-
-	chatIDRaw, err := config.GetValue(config.ChatId)
-	if err != nil {
-		return nil, err
-	}
-	chatID, err := chatIDRaw.Int64()
-	if err != nil {
-		return nil, err
+	for i, text := range cfg.Messages {
+		tpl, err := parseTemplate(cfg.Name, fmt.Sprintf("messages[%d]", i), text, cfg.Vars)
+		if err != nil {
+			return nil, err
+		}
+		tpls = append(tpls, tpl)
 	}
 
-	sendEnabledRaw, err := config.GetValue(config.SendEnabled)
-	if err != nil {
-		return nil, err
+	markup := cfg.Markup
+	if markup == nil {
+		markup = []any{}
 	}
-	sendEnabled, err := sendEnabledRaw.Bool()
+
+	return &settings{
+		messageTpls: tpls,
+		strategy:    strategy,
+		poolHash:    variantsHash(cfg.Messages),
+		markup:      markup,
+		vars:        cfg.Vars,
+		chatID:      cfg.ChatID,
+		sendEnabled: cfg.SendEnabled,
+	}, nil
+}
+
+// messageNamespace Пространство имен для UUIDv5 сообщений
+var messageNamespace = uuid.MustParse("0b3c2f9e-5d7a-4c1e-9f6b-8a2d4e6c1b30")
+
+// NewSendMessageJob создает задачу отправки. Шаблоны сообщения проверяются сразу, ошибка в них - *TemplateError.
+// pool хранит позиции ротации вариантов messages, в outbox попадают сообщения, не отправленные из-за временной ошибки
+func NewSendMessageJob(cfg config.Job, parser parser, client apiClient, ledger *Ledger, pool *Pool, outbox *Outbox) (*SendMessageJob, error) {
+	settings, err := newSettings(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	job := &SendMessageJob{
-		parser:        parser,
-		client:        client,
-		messageTplRaw: messageTplRaw,
-		markup:        markup,
-		chatID:        chatID,
-		sendEnabled:   sendEnabled,
+		name:   cfg.Name,
+		parser: parser,
+		client: client,
+		ledger: ledger,
+		pool:   pool,
+		outbox: outbox,
 	}
+	job.settings.Store(settings)
 
-	// --- Watchers ---
-
-	config.Watch(config.MessageText, func(newValue, oldValue realtimeconfig.Value) {
-		newMessageText, err := newValue.String()
-		if err != nil {
-			log.Println("Failed to parse new message in live config:", err)
-			return
-		}
-
-		job.messageTplRaw = newMessageText
-		log.Println("Applied new message template")
-	})
-
-	config.Watch(config.Markup, func(newValue, oldValue realtimeconfig.Value) {
-		newMarkupStr, err := newValue.String()
-		if err != nil {
-			log.Println("Failed to parse new markup:", err)
-			return
-		}
-
-		var newMarkup []any
-		if newMarkupStr != "" {
-			if err := json.Unmarshal([]byte(newMarkupStr), &newMarkup); err != nil {
-				log.Println("Failed to unmarshal markup:", err)
-				return
-			}
-		}
-
-		job.markup = newMarkup
-		log.Println("Applied new markup config")
-	})
-
-	config.Watch(config.ChatId, func(newValue, oldValue realtimeconfig.Value) {
-		newChatID, err := newValue.Int64()
-		if err != nil {
-			log.Println("Failed to parse new chatID in live config:", err)
-			return
-		}
+	return job, nil
+}
 
-		job.chatID = newChatID
-		log.Printf("Applied new message chatID to %d", newChatID)
-	})
+// Update атомарно подменяет настройки сообщения. Уже идущий запуск дорабатывает со старыми.
+// Если новый шаблон не разбирается, остаются прежние настройки
+func (p *SendMessageJob) Update(cfg config.Job) error {
+	apply, err := p.PrepareUpdate(cfg)
+	if err != nil {
+		return err
+	}
 
-	config.Watch(config.SendEnabled, func(newValue, oldValue realtimeconfig.Value) {
-		newSendEnabled, err := newValue.Bool()
-		if err != nil {
-			log.Println("Failed to parse new send enabled in live config:", err)
-			return
-		}
+	apply()
+	return nil
+}
 
-		job.sendEnabled = newSendEnabled
-		log.Printf("Applied new send enabled config to %t", newSendEnabled)
-	})
+// PrepareUpdate собирает и проверяет новые настройки сообщения, не трогая текущие.
+// Возвращенная функция подменяет их одним снимком, так вызывающий может применить настройки
+// только после того, как остальные изменения задачи прошли
+func (p *SendMessageJob) PrepareUpdate(cfg config.Job) (func(), error) {
+	settings, err := newSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	return job, nil
+	return func() {
+		p.settings.Store(settings)
+		log.Printf("Applied new message settings for %s", p.name)
+	}, nil
 }
 
 func (p *SendMessageJob) Name() string {
-	return SendMessageJobName
+	return p.name
 }
 
 func (p *SendMessageJob) Work(ctx context.Context) error {
-	if !p.sendEnabled {
-		log.Println("SendMessageJob is disabled")
+	settings := p.settings.Load()
+
+	if !settings.sendEnabled {
+		log.Printf("SendMessageJob %s is disabled", p.name)
 		return nil
 	}
 
-	log.Println("Starting sending message...")
+	log.Printf("Starting sending message for %s...", p.name)
 
 	requestURL, err := p.parser.GetRequestURL()
 	if err != nil {
@@ -191,65 +187,94 @@ func (p *SendMessageJob) Work(ctx context.Context) error {
 		return err
 	}
 
-	// Render template on every execution
-	renderedText, err := renderTemplate(p.messageTplRaw)
+	info, _ := cron.RunInfoFromContext(ctx)
+	loc := time.Local
+	if info.Location != nil {
+		loc = info.Location
+	}
+	now := time.Now().In(loc)
+
+	data := templateData{
+		Name:      p.name,
+		ChatID:    settings.chatID,
+		Scheduled: info.Scheduled.In(loc),
+		FiredAt:   now,
+		RunCount:  info.RunCount,
+		Next:      info.Next.In(loc),
+		Vars:      settings.vars,
+	}
+	if !info.Previous.StartedAt.IsZero() {
+		data.Prev = prevRun{
+			At:       info.Previous.StartedAt.In(loc),
+			Duration: info.Previous.Duration,
+			OK:       info.Previous.Err == nil,
+		}
+		if info.Previous.Err != nil {
+			data.Prev.Error = info.Previous.Err.Error()
+		}
+	}
+	if !info.Deadline.IsZero() {
+		data.Deadline = info.Deadline.In(loc)
+		data.TimeLeft = info.Deadline.Sub(now).Round(time.Second)
+	}
+
+	variant := p.pool.Pick(p.name, settings.poolHash, settings.strategy, len(settings.messageTpls), info.Scheduled)
+	renderedText, err := renderTemplate(settings.messageTpls[variant], now, loc, data)
 	if err != nil {
 		return err
 	}
 
+	messageID := p.messageID(info, settings.chatID, now)
+	if p.ledger.Seen(messageID) {
+		log.Printf("Message %s for %s was already sent, skipping", messageID, p.name)
+		return nil
+	}
+
 	message := Message{
-		UUID:               uuid.New(),
+		UUID:               messageID,
 		Text:               renderedText,
-		Markup:             p.markup,
+		Markup:             settings.markup,
 		Kind:               messageKind,
 		Files:              []any{},
 		SkipInviteMentions: skipInviteMentions,
 	}
 
 	body := &Body{
-		ChatID:  p.chatID,
+		ChatID:  settings.chatID,
 		Message: message,
 	}
 
 	if err := p.client.SendMessage(ctx, requestURL, cookie, headers, body); err != nil {
+		// запуск, прерванный остановкой бота, тоже не должен терять сообщение
+		stopped := errors.Is(err, context.Canceled) && errors.Is(context.Cause(ctx), cron.ErrStopped)
+		if p.outbox != nil && (p.client.Retryable(err) || errors.Is(err, context.DeadlineExceeded) || stopped) {
+			if qErr := p.outbox.Enqueue(p.name, body, err); qErr != nil {
+				log.Printf("Failed to queue message %s for %s: %v", messageID, p.name, qErr)
+			} else {
+				log.Printf("Message %s for %s queued for redelivery", messageID, p.name)
+			}
+		}
 		return err
 	}
 
+	p.ledger.Mark(messageID, time.Now())
+
 	return nil
 }
 
-func renderTemplate(input string) (string, error) {
-	funcMap := template.FuncMap{
-		"NOW": func() string {
-			return time.Now().Format(time.RFC3339)
-		},
-		"DEBUG": func() string {
-			info, ok := debug.ReadBuildInfo()
-
-			data := map[string]any{
-				"go_version": runtime.Version(),
-				"os":         runtime.GOOS,
-				"arch":       runtime.GOARCH,
-			}
-
-			if ok {
-				data["module_path"] = info.Main.Path
-			}
-
-			b, _ := json.Marshal(data)
-			return string(b)
-		},
-	}
-
-	t, err := template.New("").Funcs(funcMap).Parse(input)
-	if err != nil {
-		return "", err
+// messageID выводит UUID сообщения из задачи, чата и времени срабатывания по расписанию,
+// поэтому повторы и догоняющие запуски одного срабатывания получают тот же UUID
+func (p *SendMessageJob) messageID(info cron.RunInfo, chatID int64, now time.Time) uuid.UUID {
+	name := info.Name
+	if name == "" {
+		name = p.name
 	}
 
-	var buf bytes.Buffer
-	if err := t.Execute(&buf, nil); err != nil {
-		return "", err
+	scheduled := info.Scheduled
+	if scheduled.IsZero() {
+		scheduled = now
 	}
 
-	return buf.String(), nil
+	key := fmt.Sprintf("%s|%d|%s", name, chatID, scheduled.UTC().Format(time.RFC3339Nano))
+	return uuid.NewSHA1(messageNamespace, []byte(key))
 }
@@ -0,0 +1,121 @@
+package sender
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/internal/config"
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/cron"
+)
+
+type stubParser struct{}
+
+func (stubParser) GetHeaders() (map[string]string, error) { return map[string]string{}, nil }
+func (stubParser) GetRequestURL() (string, error)         { return "http://localhost/send", nil }
+func (stubParser) GetCookie() (string, error)             { return "session=1", nil }
+
+// hangingClient Отправка висит, пока не отменят контекст, и ошибкой отдает его отмену
+type hangingClient struct {
+	started chan struct{}
+}
+
+func (c *hangingClient) SendMessage(ctx context.Context, _ string, _ string, _ map[string]string, _ *Body) error {
+	close(c.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (c *hangingClient) Retryable(error) bool {
+	return false
+}
+
+func TestSendInterruptedByStopIsQueued(t *testing.T) {
+	tests := []struct {
+		name string
+		// interrupt прерывает идущую отправку
+		interrupt  func(m *cron.Manager, cancelTask context.CancelFunc)
+		wantQueued bool
+	}{
+		{
+			name: "manager stop",
+			interrupt: func(m *cron.Manager, _ context.CancelFunc) {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				if err := m.Stop(ctx); err == nil {
+					t.Error("Stop() error = nil, want aborted send")
+				}
+			},
+			wantQueued: true,
+		},
+		{
+			name: "task context canceled",
+			interrupt: func(m *cron.Manager, cancelTask context.CancelFunc) {
+				cancelTask()
+				if err := m.Stop(context.Background()); err != nil {
+					t.Error(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox, err := LoadOutbox(filepath.Join(t.TempDir(), "outbox.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ledger, err := LoadLedger("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			pool, err := LoadPool("")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := &hangingClient{started: make(chan struct{})}
+			job, err := NewSendMessageJob(config.Job{Name: "reminder", MessageText: "hello", SendEnabled: true},
+				stubParser{}, client, ledger, pool, outbox)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := cron.NewCronManager()
+			if err != nil {
+				t.Fatal(err)
+			}
+			taskCtx, cancelTask := context.WithCancel(context.Background())
+			defer cancelTask()
+			if err := m.AddTask(taskCtx, "0 0 9 * * *", job); err != nil {
+				t.Fatal(err)
+			}
+			m.Start()
+
+			if err := m.Trigger("reminder"); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case <-client.started:
+			case <-time.After(2 * time.Second):
+				t.Fatal("send did not start")
+			}
+
+			tt.interrupt(m, cancelTask)
+
+			var entries []OutboxEntry
+			for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+				if entries, err = outbox.List(); err != nil {
+					t.Fatal(err)
+				}
+				if len(entries) > 0 {
+					break
+				}
+			}
+			if queued := len(entries) > 0; queued != tt.wantQueued {
+				t.Errorf("message queued = %v, want %v", queued, tt.wantQueued)
+			}
+		})
+	}
+}
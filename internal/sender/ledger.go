@@ -0,0 +1,65 @@
+package sender
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/statefile"
+)
+
+// ledgerRetention Сколько помнить отправленные сообщения
+const ledgerRetention = 30 * 24 * time.Hour
+
+// Ledger Журнал уже отправленных сообщений по их детерминированным UUID.
+// Не дает повтору или догоняющему запуску отправить то же напоминание дважды
+type Ledger struct {
+	mu    sync.Mutex
+	store statefile.Store
+	Sent  map[uuid.UUID]time.Time `json:"sent"`
+}
+
+// LoadLedger читает журнал из файла path
+func LoadLedger(path string) (*Ledger, error) {
+	l := &Ledger{store: statefile.NewStore(path, "sent ledger")}
+	if err := l.store.Load(l); err != nil {
+		return nil, err
+	}
+	if l.Sent == nil {
+		l.Sent = make(map[uuid.UUID]time.Time)
+	}
+
+	return l, nil
+}
+
+// Seen сообщает, отправлялось ли уже сообщение с таким UUID
+func (l *Ledger) Seen(id uuid.UUID) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.Sent[id]
+	return ok
+}
+
+// Mark записывает сообщение как отправленное и выбрасывает старые записи
+func (l *Ledger) Mark(id uuid.UUID, sentAt time.Time) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.Sent[id] = sentAt
+	for key, t := range l.Sent {
+		if sentAt.Sub(t) > ledgerRetention {
+			delete(l.Sent, key)
+		}
+	}
+
+	l.store.SaveOrLog(l)
+}
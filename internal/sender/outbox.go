@@ -0,0 +1,302 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/statefile"
+)
+
+const (
+	// outboxPollInterval Как часто воркер проверяет очередь
+	outboxPollInterval = 15 * time.Second
+	// outboxSendTimeout Лимит на одну повторную отправку вместе с повторами клиента
+	outboxSendTimeout = time.Minute
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = 30 * time.Minute
+)
+
+// OutboxEntry Сообщение, которое не удалось отправить, с исходным телом и UUID
+type OutboxEntry struct {
+	UUID        uuid.UUID `json:"uuid"`
+	Job         string    `json:"job"`
+	Body        Body      `json:"body"`
+	EnqueuedAt  time.Time `json:"enqueued_at"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	// Stale Сообщение устарело или не может быть доставлено, повторно не отправляется
+	Stale bool `json:"stale,omitempty"`
+}
+
+// Outbox Очередь неотправленных сообщений на диске. Каждое изменение перечитывает и записывает
+// файл под межпроцессной блокировкой, поэтому команда outbox может чистить очередь, пока бот работает
+type Outbox struct {
+	mu    sync.Mutex
+	store statefile.Store
+	// entries Очередь, когда файл не задан
+	entries []OutboxEntry
+}
+
+type outboxFile struct {
+	Entries []OutboxEntry `json:"entries"`
+}
+
+// LoadOutbox открывает очередь и проверяет, что ее файл читается
+func LoadOutbox(path string) (*Outbox, error) {
+	o := &Outbox{store: statefile.NewStore(path, "outbox")}
+
+	if _, err := o.load(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// List возвращает все сообщения в очереди в порядке постановки
+func (o *Outbox) List() ([]OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.load()
+}
+
+// Enqueue ставит сообщение в очередь. Повторная постановка того же UUID только обновляет ошибку
+func (o *Outbox) Enqueue(job string, body *Body, cause error) error {
+	if o == nil {
+		return errors.New("outbox is not configured")
+	}
+
+	now := time.Now()
+	return o.update(func(entries []OutboxEntry) []OutboxEntry {
+		for i := range entries {
+			if entries[i].UUID == body.Message.UUID {
+				entries[i].LastError = cause.Error()
+				return entries
+			}
+		}
+
+		return append(entries, OutboxEntry{
+			UUID:        body.Message.UUID,
+			Job:         job,
+			Body:        *body,
+			EnqueuedAt:  now,
+			NextAttempt: now.Add(outboxBaseBackoff),
+			LastError:   cause.Error(),
+		})
+	})
+}
+
+// Purge удаляет из очереди устаревшие сообщения или, если staleOnly false, все. Возвращает число удаленных
+func (o *Outbox) Purge(staleOnly bool) (int, error) {
+	removed := 0
+	err := o.update(func(entries []OutboxEntry) []OutboxEntry {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if staleOnly && !entry.Stale {
+				kept = append(kept, entry)
+				continue
+			}
+			removed++
+		}
+		return kept
+	})
+
+	return removed, err
+}
+
+// RetryNow делает все неустаревшие сообщения доступными для отправки на ближайшем проходе воркера,
+// например после замены протухших учетных данных
+func (o *Outbox) RetryNow() error {
+	now := time.Now()
+	return o.update(func(entries []OutboxEntry) []OutboxEntry {
+		for i := range entries {
+			if !entries[i].Stale {
+				entries[i].NextAttempt = now
+			}
+		}
+		return entries
+	})
+}
+
+func (o *Outbox) update(fn func(entries []OutboxEntry) []OutboxEntry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.store.Locked(func() error {
+		entries, err := o.load()
+		if err != nil {
+			return err
+		}
+
+		entries = fn(entries)
+
+		if o.store.InMemory() {
+			o.entries = entries
+			return nil
+		}
+
+		return o.store.Save(outboxFile{Entries: entries})
+	})
+}
+
+// load читает очередь. Вызывается под o.mu
+func (o *Outbox) load() ([]OutboxEntry, error) {
+	if o.store.InMemory() {
+		return slices.Clone(o.entries), nil
+	}
+
+	var f outboxFile
+	if err := o.store.Load(&f); err != nil {
+		return nil, err
+	}
+
+	return f.Entries, nil
+}
+
+// OutboxWorker Повторно отправляет сообщения из очереди, когда API снова доступен
+type OutboxWorker struct {
+	outbox     *Outbox
+	parser     parser
+	client     apiClient
+	ledger     *Ledger
+	staleAfter time.Duration
+}
+
+// NewOutboxWorker создает воркер. Сообщения старше staleAfter помечаются устаревшими и больше не отправляются
+func NewOutboxWorker(outbox *Outbox, parser parser, client apiClient, ledger *Ledger, staleAfter time.Duration) *OutboxWorker {
+	return &OutboxWorker{
+		outbox:     outbox,
+		parser:     parser,
+		client:     client,
+		ledger:     ledger,
+		staleAfter: staleAfter,
+	}
+}
+
+// Run обрабатывает очередь, пока не отменен ctx
+func (w *OutboxWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue отправляет подошедшие по времени сообщения по порядку. Если API все еще
+// отвечает временной ошибкой, проход прерывается до следующего тика
+func (w *OutboxWorker) deliverDue(ctx context.Context) {
+	entries, err := w.outbox.List()
+	if err != nil {
+		log.Println("Failed to read outbox:", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		now := time.Now()
+		if entry.Stale || now.Before(entry.NextAttempt) {
+			continue
+		}
+
+		if w.ledger.Seen(entry.UUID) {
+			w.finish(entry.UUID, nil)
+			continue
+		}
+
+		if w.staleAfter > 0 && now.Sub(entry.EnqueuedAt) > w.staleAfter {
+			log.Printf("Outbox message %s for %s is older than %s, marked stale", entry.UUID, entry.Job, w.staleAfter)
+			w.finish(entry.UUID, func(e *OutboxEntry) { e.Stale = true })
+			continue
+		}
+
+		err := w.send(ctx, entry)
+		if err == nil {
+			w.ledger.Mark(entry.UUID, time.Now())
+			log.Printf("Outbox message %s for %s delivered after %d attempts", entry.UUID, entry.Job, entry.Attempts+1)
+			w.finish(entry.UUID, nil)
+			continue
+		}
+
+		retryable := w.client.Retryable(err) || errors.Is(err, context.DeadlineExceeded)
+		w.finish(entry.UUID, func(e *OutboxEntry) {
+			e.Attempts++
+			e.LastError = err.Error()
+			e.NextAttempt = time.Now().Add(outboxBackoff(e.Attempts))
+			e.Stale = !retryable
+		})
+
+		if !retryable {
+			log.Printf("Outbox message %s for %s failed permanently, marked stale: %v", entry.UUID, entry.Job, err)
+			continue
+		}
+
+		log.Printf("Outbox message %s for %s is still failing, next attempt in %s: %v", entry.UUID, entry.Job, outboxBackoff(entry.Attempts+1), err)
+		return
+	}
+}
+
+func (w *OutboxWorker) send(ctx context.Context, entry OutboxEntry) error {
+	requestURL, err := w.parser.GetRequestURL()
+	if err != nil {
+		return err
+	}
+
+	cookie, err := w.parser.GetCookie()
+	if err != nil {
+		return err
+	}
+
+	headers, err := w.parser.GetHeaders()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outboxSendTimeout)
+	defer cancel()
+
+	return w.client.SendMessage(ctx, requestURL, cookie, headers, &entry.Body)
+}
+
+// finish применяет изменение к сообщению в очереди или, если change nil, удаляет его
+func (w *OutboxWorker) finish(id uuid.UUID, change func(e *OutboxEntry)) {
+	err := w.outbox.update(func(entries []OutboxEntry) []OutboxEntry {
+		for i := range entries {
+			if entries[i].UUID != id {
+				continue
+			}
+			if change == nil {
+				return slices.Delete(entries, i, i+1)
+			}
+			change(&entries[i])
+			break
+		}
+		return entries
+	})
+	if err != nil {
+		log.Println("Failed to update outbox:", err)
+	}
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseBackoff
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxBackoff)
+}
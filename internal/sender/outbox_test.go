@@ -0,0 +1,50 @@
+package sender
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestOutboxConcurrentWriters Бот и команда outbox открывают одну очередь независимо:
+// изменения обоих должны сохраниться
+func TestOutboxConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+
+	const perWriter = 50
+	var wg sync.WaitGroup
+	for range 2 {
+		outbox, err := LoadOutbox(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWriter {
+				body := &Body{Message: Message{UUID: uuid.New()}}
+				if err := outbox.Enqueue("job", body, errors.New("unavailable")); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	outbox, err := LoadOutbox(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := outbox.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2*perWriter {
+		t.Errorf("outbox has %d entries, want %d", len(entries), 2*perWriter)
+	}
+}
@@ -0,0 +1,149 @@
+package sender
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/statefile"
+)
+
+// PoolStrategy Как выбирается вариант сообщения из списка messages
+type PoolStrategy int
+
+const (
+	// PoolRoundRobin варианты по очереди
+	PoolRoundRobin PoolStrategy = iota
+	// PoolRandom случайный вариант на каждый запуск
+	PoolRandom
+	// PoolShuffle случайный порядок без повторов, пока не будут использованы все варианты
+	PoolShuffle
+)
+
+func (s PoolStrategy) String() string {
+	switch s {
+	case PoolRandom:
+		return "random"
+	case PoolShuffle:
+		return "shuffle"
+	default:
+		return "round_robin"
+	}
+}
+
+// ParsePoolStrategy разбирает стратегию из конфига. Пустая строка означает round_robin
+func ParsePoolStrategy(s string) (PoolStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "round_robin":
+		return PoolRoundRobin, nil
+	case "random":
+		return PoolRandom, nil
+	case "shuffle":
+		return PoolShuffle, nil
+	default:
+		return PoolRoundRobin, fmt.Errorf("unknown message strategy %q", s)
+	}
+}
+
+// Pool Позиции ротации вариантов сообщений по задачам. Переживает рестарт,
+// а при изменении списка вариантов задачи ее позиция сбрасывается
+type Pool struct {
+	mu    sync.Mutex
+	store statefile.Store
+	Jobs  map[string]*poolCursor `json:"jobs"`
+}
+
+type poolCursor struct {
+	// Hash Отпечаток списка вариантов, по которому ведется ротация
+	Hash string `json:"hash"`
+	// Next Позиция следующего варианта: в списке для round_robin, в Order для shuffle
+	Next  int   `json:"next"`
+	Order []int `json:"order,omitempty"`
+	// Firing Срабатывание, для которого выбран вариант Last. Повтор того же срабатывания
+	// получает тот же вариант и не сдвигает ротацию
+	Firing time.Time `json:"firing,omitzero"`
+	Last   int       `json:"last"`
+}
+
+// LoadPool читает позиции ротации из файла path
+func LoadPool(path string) (*Pool, error) {
+	p := &Pool{store: statefile.NewStore(path, "message pool state")}
+	if err := p.store.Load(p); err != nil {
+		return nil, err
+	}
+	if p.Jobs == nil {
+		p.Jobs = make(map[string]*poolCursor)
+	}
+
+	return p, nil
+}
+
+// Pick выбирает вариант из n для срабатывания firing задачи job и сдвигает ротацию
+func (p *Pool) Pick(job string, hash string, strategy PoolStrategy, n int, firing time.Time) int {
+	if n <= 1 {
+		return 0
+	}
+	if p == nil {
+		return rand.IntN(n)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.Jobs[job]
+	if !ok || c.Hash != hash {
+		if ok {
+			log.Printf("Message variants of %s changed, rotation restarted", job)
+		}
+		c = &poolCursor{Hash: hash, Last: -1}
+		p.Jobs[job] = c
+	}
+
+	if !firing.IsZero() && c.Firing.Equal(firing) && c.Last >= 0 && c.Last < n {
+		return c.Last
+	}
+
+	var i int
+	switch strategy {
+	case PoolRandom:
+		i = rand.IntN(n)
+	case PoolShuffle:
+		if c.Next >= len(c.Order) || len(c.Order) != n {
+			c.Order = rand.Perm(n)
+			c.Next = 0
+			// новый круг не начинается с варианта, которым закончился прошлый
+			if c.Order[0] == c.Last {
+				c.Order[0], c.Order[n-1] = c.Order[n-1], c.Order[0]
+			}
+		}
+		i = c.Order[c.Next]
+		c.Next++
+	default:
+		i = c.Next % n
+		c.Next = (i + 1) % n
+	}
+
+	c.Firing = firing
+	c.Last = i
+	p.saveLocked()
+
+	return i
+}
+
+func (p *Pool) saveLocked() {
+	p.store.SaveOrLog(p)
+}
+
+// variantsHash Отпечаток списка вариантов
+func variantsHash(variants []string) string {
+	h := sha256.New()
+	for _, v := range variants {
+		fmt.Fprintf(h, "%d:%s", len(v), v)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
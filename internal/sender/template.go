@@ -0,0 +1,206 @@
+package sender
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// templateData Данные, доступные в шаблоне message_text через точку. Все времена - в часовом поясе задачи
+type templateData struct {
+	// Name Имя задачи из конфига
+	Name   string
+	ChatID int64
+	// Scheduled Время срабатывания по расписанию, FiredAt - фактическое время запуска
+	Scheduled time.Time
+	FiredAt   time.Time
+	// RunCount Порядковый номер запуска, считая только успешные
+	RunCount int64
+	// Prev Итог предыдущего запуска, нулевой до первого запуска после старта
+	Prev prevRun
+	// Next Следующий запуск по расписанию, нулевой у разовых задач
+	Next time.Time
+	// Vars Переменные задачи из vars в конфиге
+	Vars map[string]any
+	// Deadline Дедлайн, о котором напоминает задача типа deadline
+	Deadline time.Time
+	// TimeLeft Сколько осталось до дедлайна
+	TimeLeft time.Duration
+}
+
+// prevRun Итог предыдущего запуска для шаблона
+type prevRun struct {
+	At       time.Time
+	Duration time.Duration
+	OK       bool
+	// Error Текст ошибки, пустой при успехе
+	Error string
+}
+
+// TemplateError Ошибка в шаблоне сообщения с позицией в тексте шаблона
+type TemplateError struct {
+	// Field Поле конфига с шаблоном: message_text или messages[i]
+	Field  string
+	Line   int
+	Column int
+	Msg    string
+	Err    error
+}
+
+func (e *TemplateError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("invalid %s at line %d, column %d: %s", e.Field, e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("invalid %s at line %d: %s", e.Field, e.Line, e.Msg)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// templatePosRe Позиция в ошибках text/template: "template: name:3: ..." при разборе
+// и "template: name:3:14: ..." при выполнении
+var templatePosRe = regexp.MustCompile(`^template: .*?:(\d+):(?:(\d+):)? (.*)$`)
+
+// parseTemplate разбирает шаблон и пробно выполняет его на данных-образце, чтобы ошибки вроде
+// неизвестного поля или функции находились при загрузке конфига, а не в момент отправки
+func parseTemplate(name, field, text string, vars map[string]any) (*template.Template, error) {
+	now := time.Now()
+
+	t, err := template.New(name).Funcs(TemplateFuncs(now, nil)).Parse(text)
+	if err != nil {
+		return nil, newTemplateError(field, text, err)
+	}
+
+	sample := templateData{
+		Name:      name,
+		Scheduled: now,
+		FiredAt:   now,
+		RunCount:  1,
+		Next:      now,
+		Vars:      vars,
+		Deadline:  now.Add(time.Hour),
+		TimeLeft:  time.Hour,
+	}
+	if _, err := renderTemplate(t, now, nil, sample); err != nil {
+		return nil, newTemplateError(field, text, err)
+	}
+
+	return t, nil
+}
+
+// renderTemplate выполняет заранее разобранный шаблон. Функции привязываются к моменту рендера
+// на копии шаблона, поэтому кэшированный шаблон можно выполнять из нескольких запусков сразу
+func renderTemplate(t *template.Template, now time.Time, loc *time.Location, data templateData) (string, error) {
+	t, err := t.Clone()
+	if err != nil {
+		return "", err
+	}
+	t.Funcs(TemplateFuncs(now, loc))
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func newTemplateError(field, text string, err error) *TemplateError {
+	m := templatePosRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return &TemplateError{Field: field, Msg: err.Error(), Err: err}
+	}
+
+	line, _ := strconv.Atoi(m[1])
+
+	var column int
+	if m[2] != "" {
+		// при выполнении text/template указывает смещение в байтах от начала строки, считая с нуля
+		offset, _ := strconv.Atoi(m[2])
+		column = runeColumn(text, line, offset)
+	} else {
+		// при разборе text/template сообщает только строку, колонку ищем сами
+		column = actionColumn(text, line)
+	}
+
+	return &TemplateError{Field: field, Line: line, Column: column, Msg: m[3], Err: err}
+}
+
+// actionColumn возвращает колонку первого действия {{...}} в строке line, которое не разбирается
+// само по себе, или первого действия в строке, если виновника определить не удалось
+func actionColumn(text string, line int) int {
+	lines := strings.Split(text, "\n")
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	l := lines[line-1]
+
+	first := 0
+	for off := 0; ; {
+		i := strings.Index(l[off:], "{{")
+		if i < 0 {
+			break
+		}
+		start := off + i
+
+		action := l[start:]
+		end := strings.Index(action, "}}")
+		if end >= 0 {
+			action = action[:end+2]
+		}
+
+		// как и text/template при выполнении, указываем на начало содержимого действия
+		column := runeColumn(text, line, start+2)
+		if first == 0 {
+			first = column
+		}
+		if end < 0 || !actionParses(action) {
+			return column
+		}
+
+		off = start + end + 2
+	}
+
+	return first
+}
+
+// runeColumn переводит байтовое смещение в строке line в номер колонки в символах, считая с единицы
+func runeColumn(text string, line, offset int) int {
+	lines := strings.Split(text, "\n")
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	l := lines[line-1]
+
+	if offset > len(l) {
+		offset = len(l)
+	}
+
+	return utf8.RuneCountInString(l[:offset]) + 1
+}
+
+// actionParses проверяет одно действие в отрыве от остального шаблона. Открывающие блоки
+// закрываются искусственно, а else и end сами по себе не проверить, их считаем корректными
+func actionParses(action string) bool {
+	inner := strings.TrimSuffix(strings.TrimPrefix(action, "{{"), "}}")
+	inner = strings.TrimSpace(strings.Trim(inner, "-"))
+
+	fields := strings.Fields(inner)
+	if len(fields) > 0 {
+		switch fields[0] {
+		case "end", "else":
+			return true
+		case "if", "range", "with", "block", "define":
+			action += "{{end}}"
+		}
+	}
+
+	_, err := template.New("").Funcs(TemplateFuncs(time.Now(), nil)).Parse(action)
+	return err == nil
+}
@@ -0,0 +1,107 @@
+package sender
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTemplateErrorPosition(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantLine   int
+		wantColumn int
+	}{
+		{name: "parse error", text: "{{ .Name", wantLine: 1, wantColumn: 3},
+		{name: "parse error after multibyte text", text: "Привет {{ .Name", wantLine: 1, wantColumn: 10},
+		{name: "parse error on second line", text: "ok\nпривет {{ .Name }} и {{ nosuchfunc }}", wantLine: 2, wantColumn: 24},
+		{name: "exec error", text: "{{ .Missing }}", wantLine: 1, wantColumn: 4},
+		{name: "exec error after multibyte text", text: "Дедлайн: {{ .Missing }}", wantLine: 1, wantColumn: 13},
+		{name: "exec error on third line", text: "раз\nдва\nтри {{ .Name }} {{ .Missing }}", wantLine: 3, wantColumn: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTemplate("reminder", "messages[1]", tt.text, nil)
+
+			var tplErr *TemplateError
+			if !errors.As(err, &tplErr) {
+				t.Fatalf("parseTemplate() error = %v, want *TemplateError", err)
+			}
+			if tplErr.Field != "messages[1]" || tplErr.Line != tt.wantLine || tplErr.Column != tt.wantColumn {
+				t.Errorf("parseTemplate() error at %s line %d column %d, want messages[1] line %d column %d: %v",
+					tplErr.Field, tplErr.Line, tplErr.Column, tt.wantLine, tt.wantColumn, err)
+			}
+		})
+	}
+}
+
+func TestActionColumn(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		line int
+		want int
+	}{
+		{name: "broken action after good one", text: "{{ .Name }} {{ nosuchfunc }}", line: 1, want: 15},
+		{name: "unclosed action", text: "день {{ .Name", line: 1, want: 8},
+		{name: "no culprit falls back to first action", text: "{{ .Name }} и {{ .Vars }}", line: 1, want: 3},
+		{name: "line without actions", text: "{{ .Name }}\nтекст", line: 2, want: 0},
+		{name: "line out of range", text: "{{ .Name }}", line: 3, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := actionColumn(tt.text, tt.line); got != tt.want {
+				t.Errorf("actionColumn(%q, %d) = %d, want %d", tt.text, tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionParses(t *testing.T) {
+	tests := []struct {
+		action string
+		want   bool
+	}{
+		{"{{ .Name }}", true},
+		{"{{- .Name -}}", true},
+		{"{{ if .Name }}", true},
+		{"{{ range .Vars }}", true},
+		{"{{ else }}", true},
+		{"{{ end }}", true},
+		{"{{ nosuchfunc }}", false},
+		{"{{ .Name", false},
+		{"{{ if }}", false},
+	}
+
+	for _, tt := range tests {
+		if got := actionParses(tt.action); got != tt.want {
+			t.Errorf("actionParses(%q) = %v, want %v", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestRuneColumn(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		line   int
+		offset int
+		want   int
+	}{
+		{name: "ascii", text: "abc", line: 1, offset: 2, want: 3},
+		{name: "multibyte", text: "день", line: 1, offset: 4, want: 3},
+		{name: "second line", text: "abc\nпн {{", line: 2, offset: 5, want: 4},
+		{name: "offset past end of line", text: "ok\nabc", line: 1, offset: 10, want: 3},
+		{name: "line out of range", text: "abc", line: 2, offset: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runeColumn(tt.text, tt.line, tt.offset); got != tt.want {
+				t.Errorf("runeColumn(%q, %d, %d) = %d, want %d", tt.text, tt.line, tt.offset, got, tt.want)
+			}
+		})
+	}
+}
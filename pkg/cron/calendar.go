@@ -0,0 +1,242 @@
+package cron
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const calendarDateLayout = "2006-01-02"
+
+// Calendar Производственный календарь: праздники и перенесенные рабочие дни поверх обычной пятидневки
+type Calendar struct {
+	mu          sync.RWMutex
+	holidays    map[string]string
+	workingDays map[string]string
+}
+
+type calendarFile struct {
+	Holidays    []calendarDay `yaml:"holidays"`
+	WorkingDays []calendarDay `yaml:"working_days"`
+}
+
+type calendarDay struct {
+	Date string `yaml:"date"`
+	Name string `yaml:"name"`
+}
+
+func NewCalendar() *Calendar {
+	return &Calendar{
+		holidays:    make(map[string]string),
+		workingDays: make(map[string]string),
+	}
+}
+
+// LoadCalendar читает календарь из YAML или ICS файла
+func LoadCalendar(path string) (*Calendar, error) {
+	c := NewCalendar()
+	if err := c.Load(path); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load атомарно заменяет содержимое календаря данными из файла.
+// Формат определяется по расширению: .ics или .yaml/.yml
+func (c *Calendar) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var holidays, workingDays map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics":
+		holidays, workingDays, err = parseICSCalendar(data)
+	case ".yaml", ".yml":
+		holidays, workingDays, err = parseYAMLCalendar(data)
+	default:
+		err = fmt.Errorf("unsupported calendar format: %s", path)
+	}
+	if err != nil {
+		return fmt.Errorf("load calendar %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.holidays = holidays
+	c.workingDays = workingDays
+
+	return nil
+}
+
+// IsBusinessDay сообщает, рабочий ли день t (в его собственном часовом поясе),
+// и если нет, то почему
+func (c *Calendar) IsBusinessDay(t time.Time) (bool, string) {
+	day := t.Format(calendarDateLayout)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.workingDays[day]; ok {
+		return true, ""
+	}
+
+	if name, ok := c.holidays[day]; ok {
+		if name == "" {
+			return false, fmt.Sprintf("%s is a holiday", day)
+		}
+		return false, fmt.Sprintf("%s is a holiday (%s)", day, name)
+	}
+
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return false, fmt.Sprintf("%s is a weekend (%s)", day, wd)
+	}
+
+	return true, ""
+}
+
+func parseYAMLCalendar(data []byte) (map[string]string, map[string]string, error) {
+	var file calendarFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, nil, err
+	}
+
+	holidays, err := calendarDays(file.Holidays)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workingDays, err := calendarDays(file.WorkingDays)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return holidays, workingDays, nil
+}
+
+func calendarDays(days []calendarDay) (map[string]string, error) {
+	result := make(map[string]string, len(days))
+	for _, d := range days {
+		date, err := time.Parse(calendarDateLayout, d.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", d.Date, err)
+		}
+		result[date.Format(calendarDateLayout)] = d.Name
+	}
+	return result, nil
+}
+
+// parseICSCalendar разбирает VEVENT-ы на весь день. События с CATEGORIES:WORKDAY
+// считаются перенесенными рабочими днями, остальные - праздниками.
+// DTEND, как и положено в ICS, не включается в период
+func parseICSCalendar(data []byte) (map[string]string, map[string]string, error) {
+	holidays := make(map[string]string)
+	workingDays := make(map[string]string)
+
+	var (
+		inEvent    bool
+		start, end time.Time
+		summary    string
+		workday    bool
+	)
+
+	for _, line := range unfoldICSLines(data) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		prop, _, _ := strings.Cut(name, ";")
+
+		switch strings.ToUpper(prop) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent = true
+				start, end, summary, workday = time.Time{}, time.Time{}, "", false
+			}
+		case "END":
+			if !strings.EqualFold(value, "VEVENT") || !inEvent {
+				continue
+			}
+			inEvent = false
+
+			if start.IsZero() {
+				return nil, nil, fmt.Errorf("event %q without DTSTART", summary)
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+
+			target := holidays
+			if workday {
+				target = workingDays
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				target[d.Format(calendarDateLayout)] = summary
+			}
+		case "DTSTART", "DTEND":
+			if !inEvent {
+				continue
+			}
+			date, err := parseICSDate(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			if strings.EqualFold(prop, "DTSTART") {
+				start = date
+			} else {
+				end = date
+			}
+		case "SUMMARY":
+			if inEvent {
+				summary = strings.ReplaceAll(value, `\,`, ",")
+			}
+		case "CATEGORIES":
+			if inEvent {
+				for _, category := range strings.Split(value, ",") {
+					if strings.EqualFold(strings.TrimSpace(category), "WORKDAY") {
+						workday = true
+					}
+				}
+			}
+		}
+	}
+
+	return holidays, workingDays, nil
+}
+
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid ics date %q", value)
+	}
+
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid ics date %q: %w", value, err)
+	}
+	return date, nil
+}
+
+// unfoldICSLines склеивает перенесенные строки (RFC 5545, 3.1)
+func unfoldICSLines(data []byte) []string {
+	var lines []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
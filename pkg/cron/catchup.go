@@ -0,0 +1,111 @@
+package cron
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// CatchUpPolicy Что делать с запуском, пропущенным, пока бот был выключен
+type CatchUpPolicy int
+
+const (
+	// CatchUpSkip пропущенный запуск не выполняется
+	CatchUpSkip CatchUpPolicy = iota
+	// CatchUpRunOnce пропущенный запуск выполняется один раз сразу после старта
+	CatchUpRunOnce
+)
+
+func (p CatchUpPolicy) String() string {
+	switch p {
+	case CatchUpRunOnce:
+		return "run_once"
+	default:
+		return "skip"
+	}
+}
+
+// ParseCatchUpPolicy разбирает политику из конфига. Пустая строка означает skip
+func ParseCatchUpPolicy(s string) (CatchUpPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "skip":
+		return CatchUpSkip, nil
+	case "run_once":
+		return CatchUpRunOnce, nil
+	default:
+		return CatchUpSkip, fmt.Errorf("unknown catch-up policy %q", s)
+	}
+}
+
+// catchUp ищет последний запуск, пропущенный с момента последнего успешного выполнения,
+// и, если он попал в окно догона, планирует его согласно политике задачи. Окно отправки и джиттер
+// применяются так же, как к обычному запуску. Возвращает true, если запуск запланирован
+func (m *Manager) catchUp(e *entry, now time.Time) bool {
+	name := e.task.Name()
+
+	ts := m.state.track(name, now)
+	base := ts.LastRun
+	if base.IsZero() {
+		base = ts.Since
+	}
+	if base.IsZero() {
+		return false
+	}
+
+	window := e.opts.catchUpWindow
+	if window <= 0 {
+		window = m.catchUpWindow
+	}
+
+	first := e.schedule.Next(base)
+	if first.IsZero() || first.After(now) {
+		return false
+	}
+
+	cutoff := now.Add(-window)
+	from := base
+	if cutoff.After(from) {
+		from = cutoff
+	}
+
+	var missed time.Time
+	for next := e.schedule.Next(from); !next.IsZero() && !next.After(now); next = e.schedule.Next(next) {
+		missed = next
+	}
+
+	if missed.IsZero() {
+		log.Printf("task %s missed run at %s, outside catch-up window %s", name, first.Format(time.RFC3339), window)
+		return false
+	}
+
+	if e.opts.catchUpPolicy != CatchUpRunOnce {
+		log.Printf("task %s missed run at %s, skipped by catch-up policy", name, missed.Format(time.RFC3339))
+		return false
+	}
+
+	f := e.planned.catchUp(missed, now)
+	if f.planned.IsZero() {
+		log.Printf("task %s missed run at %s, dropped by send window %s", name, missed.Format(time.RFC3339), e.opts.window)
+		return false
+	}
+
+	log.Printf("task %s missed run at %s, catching up at %s", name, missed.Format(time.RFC3339), f.planned.Format(time.RFC3339))
+	info := RunInfo{
+		Name:      name,
+		Location:  e.location,
+		Scheduled: f.scheduled,
+		Planned:   f.planned,
+	}
+	time.AfterFunc(f.planned.Sub(now), func() {
+		// задачу могли удалить или заменить, пока догоняющий запуск ждал окна
+		m.mu.Lock()
+		current := m.entries[name] == e
+		m.mu.Unlock()
+
+		if current {
+			m.run(e, info)
+		}
+	})
+	return true
+}
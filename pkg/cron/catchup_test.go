@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// dailySpec Выражение, срабатывающее каждый день в момент t
+func dailySpec(t time.Time) string {
+	return fmt.Sprintf("%d %d %d * * *", t.Second(), t.Minute(), t.Hour())
+}
+
+// startWithMissedRun запускает Manager с задачей, последний успешный запуск которой был два дня назад,
+// поэтому срабатывание в missed пропущено
+func startWithMissedRun(t *testing.T, missed time.Time, opts ...TaskOption) (*Manager, *testTask) {
+	t.Helper()
+
+	m, err := NewCronManager(WithLocation(testZone))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := &testTask{name: "report", infos: make(chan RunInfo, 1)}
+	m.state.setLastRun(task.name, missed.AddDate(0, 0, -2))
+	if err := m.AddTask(context.Background(), dailySpec(missed.In(testZone)), task, opts...); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Start()
+	t.Cleanup(func() { _ = m.Stop(context.Background()) })
+
+	return m, task
+}
+
+func TestCatchUpRunsMissedFiringOnce(t *testing.T) {
+	missed := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	_, task := startWithMissedRun(t, missed, WithCatchUp(CatchUpRunOnce, 4*time.Hour))
+
+	select {
+	case info := <-task.infos:
+		if !info.Scheduled.Equal(missed) {
+			t.Errorf("RunInfo.Scheduled = %s, want missed firing %s", info.Scheduled, missed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("missed firing was not caught up")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if runs := task.runs.Load(); runs != 1 {
+		t.Errorf("task ran %d times, want once", runs)
+	}
+}
+
+func TestCatchUpSkipsMissedFiring(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []TaskOption
+	}{
+		{name: "skip policy", opts: []TaskOption{WithCatchUp(CatchUpSkip, 4*time.Hour)}},
+		{name: "outside catch-up window", opts: []TaskOption{WithCatchUp(CatchUpRunOnce, time.Hour)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missed := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+			_, task := startWithMissedRun(t, missed, tt.opts...)
+
+			time.Sleep(300 * time.Millisecond)
+			if runs := task.runs.Load(); runs != 0 {
+				t.Errorf("task ran %d times, want missed firing skipped", runs)
+			}
+		})
+	}
+}
+
+func TestCatchUpWaitsForSendWindow(t *testing.T) {
+	for _, policy := range []WindowPolicy{WindowDefer, WindowDrop} {
+		t.Run(policy.String(), func(t *testing.T) {
+			now := time.Now().In(testZone)
+			missed := now.Add(-3 * time.Hour).Truncate(time.Second)
+			// окно отправки не содержит ни пропущенное срабатывание, ни текущий момент
+			window := mustWindow(t, fmt.Sprintf("%s-%s", now.Add(2*time.Hour).Format("15:04"), now.Add(3*time.Hour).Format("15:04")))
+
+			_, task := startWithMissedRun(t, missed, WithCatchUp(CatchUpRunOnce, 4*time.Hour), WithSendWindow(*window, policy))
+
+			time.Sleep(300 * time.Millisecond)
+			if runs := task.runs.Load(); runs != 0 {
+				t.Errorf("task ran %d times outside send window", runs)
+			}
+		})
+	}
+}
+
+func TestLastRunSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cron_state.json")
+	lastRun := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	m, err := NewCronManager(WithStateFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.state.setLastRun("report", lastRun)
+
+	restarted, err := NewCronManager(WithStateFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restarted.state.get("report").LastRun; !got.Equal(lastRun) {
+		t.Errorf("LastRun after restart = %s, want %s", got, lastRun)
+	}
+}
@@ -0,0 +1,99 @@
+package cron
+
+import (
+	"log"
+	"slices"
+	"time"
+)
+
+// Trigger запускает задачу немедленно, вне расписания. Пауза и календарь при ручном запуске не учитываются.
+// Для серии напоминаний о дедлайне запускается ближайшее напоминание
+func (m *Manager) Trigger(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.resolveLocked(name)
+	if len(entries) == 0 {
+		return ErrSpecifiedTaskNotFound
+	}
+	e := entries[0]
+	name = e.task.Name()
+
+	now := time.Now()
+	info := RunInfo{
+		Name:      name,
+		Location:  e.location,
+		Scheduled: now,
+		Planned:   now,
+		Manual:    true,
+	}
+
+	log.Printf("task %s triggered manually", name)
+	go m.run(e, info)
+
+	return nil
+}
+
+// Pause приостанавливает задачу до until, сохраняя ее расписание. Нулевое until означает паузу до Resume.
+// Пауза серии напоминаний о дедлайне ставится на все ее напоминания.
+// Пауза сохраняется в файле состояния и переживает рестарт
+func (m *Manager) Pause(name string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.resolveLocked(name)
+	if len(entries) == 0 {
+		return ErrSpecifiedTaskNotFound
+	}
+
+	for _, e := range entries {
+		m.state.setPause(e.task.Name(), true, until)
+	}
+
+	if until.IsZero() {
+		log.Printf("task %s paused until resumed", name)
+	} else {
+		log.Printf("task %s paused until %s", name, until.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Resume снимает паузу с задачи или со всех напоминаний серии
+func (m *Manager) Resume(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.resolveLocked(name)
+	if len(entries) == 0 {
+		return ErrSpecifiedTaskNotFound
+	}
+
+	for _, e := range entries {
+		m.state.setPause(e.task.Name(), false, time.Time{})
+	}
+
+	log.Printf("task %s resumed", name)
+
+	return nil
+}
+
+// resolveLocked возвращает задачу по имени, а для имени серии напоминаний о дедлайне -
+// все напоминания серии, начиная с ближайшего. Вызывается под m.mu
+func (m *Manager) resolveLocked(name string) []*entry {
+	if e, exists := m.entries[name]; exists {
+		return []*entry{e}
+	}
+
+	var group []*entry
+	for _, e := range m.entries {
+		if e.group == name {
+			group = append(group, e)
+		}
+	}
+	slices.SortFunc(group, func(a, b *entry) int {
+		return m.nextLocked(a).Compare(m.nextLocked(b))
+	})
+
+	return group
+}
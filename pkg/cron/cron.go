@@ -5,29 +5,103 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
 var ErrSpecifiedTaskNotFound = errors.New("specified task not found")
 
+type entry struct {
+	id   cron.EntryID
+	spec string
+	// schedule Расписание по самому выражению, без окна и джиттера
+	schedule cron.Schedule
+	// planned Расписание, отданное robfig/cron: с окном и джиттером
+	planned  *plannedSchedule
+	location *time.Location
+	opts     taskOptions
+	ctx      context.Context
+	task     Task
+	// wrapped Задача, обернутая глобальными и собственными обертками
+	wrapped Task
+	// oneShot Разовая задача: после запуска удаляет себя
+	oneShot bool
+	// group Имя серии напоминаний о дедлайне, к которой относится задача
+	group    string
+	deadline time.Time
+
+	// running занят, пока выполняется Work, используется политикой наложения
+	running  chan struct{}
+	skipped  atomic.Int64
+	overruns atomic.Int64
+
+	resultMu   sync.Mutex
+	lastResult RunResult
+}
+
 type Manager struct {
-	cron    *cron.Cron
-	mu      sync.Mutex
-	entries map[string]cron.EntryID
+	cron     *cron.Cron
+	cronOpts []cron.Option
+	parser   cron.Parser
+	location *time.Location
+	calendar *Calendar
+	mu       sync.Mutex
+
+	middlewares []Middleware
+	entries     map[string]*entry
+
+	state         *state
+	statePath     string
+	catchUpWindow time.Duration
+	started       bool
+
+	// rootCtx отменяется при аварийном завершении Stop, от него зависят контексты всех запусков
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
+
+	runsMu   sync.Mutex
+	runs     sync.WaitGroup
+	active   map[string]int
+	stopping bool
 }
 
-func NewCronManager() *Manager {
-	return &Manager{
-		cron:    cron.New(cron.WithSeconds()),
-		mu:      sync.Mutex{},
-		entries: make(map[string]cron.EntryID),
+func NewCronManager(opts ...Option) (*Manager, error) {
+	m := &Manager{
+		cronOpts:    []cron.Option{cron.WithSeconds()},
+		parser:      cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor),
+		location:    time.Local,
+		calendar:    NewCalendar(),
+		middlewares: DefaultMiddleware(),
+		mu:          sync.Mutex{},
+		entries:     make(map[string]*entry),
+		active:      make(map[string]int),
+	}
+	m.rootCtx, m.rootCancel = context.WithCancel(context.Background())
+
+	for _, opt := range opts {
+		opt(m)
 	}
+
+	m.cron = cron.New(m.cronOpts...)
+
+	m.state = newState()
+	if m.statePath != "" {
+		st, err := loadState(m.statePath)
+		if err != nil {
+			return nil, fmt.Errorf("load cron state: %w", err)
+		}
+		m.state = st
+	}
+
+	return m, nil
 }
 
 // AddTask добавляет задачу по cron-выражению
-func (m *Manager) AddTask(ctx context.Context, spec string, task Task) error {
+func (m *Manager) AddTask(ctx context.Context, spec string, task Task, opts ...TaskOption) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -35,36 +109,102 @@ func (m *Manager) AddTask(ctx context.Context, spec string, task Task) error {
 		return fmt.Errorf("task %s already exists", task.Name())
 	}
 
-	id, err := m.cron.AddFunc(spec, func() {
-		if err := task.Work(ctx); err != nil {
-			log.Printf("task %s failed: %v", task.Name(), err)
-			return
-		}
-
-		log.Printf("task %s completed successfully", task.Name())
-	})
+	o := newTaskOptions(opts)
 
+	schedule, loc, err := m.parseSpec(spec, o.location)
 	if err != nil {
 		return err
 	}
 
-	m.entries[task.Name()] = id
+	m.addEntry(ctx, spec, schedule, loc, task, o)
 
 	log.Printf("task %s added", task.Name())
 	return nil
 }
 
+// addEntry регистрирует задачу в cron. Вызывается под m.mu
+func (m *Manager) addEntry(ctx context.Context, spec string, schedule cron.Schedule, loc *time.Location, task Task, o taskOptions) *entry {
+	e := &entry{
+		spec:     spec,
+		schedule: schedule,
+		location: loc,
+		opts:     o,
+		ctx:      ctx,
+		task:     task,
+		wrapped:  Chain(task, m.taskMiddlewares(o)...),
+		running:  make(chan struct{}, 1),
+	}
+	e.planned = plan(schedule, loc, o)
+	e.id = m.cron.Schedule(e.planned, m.wrap(e))
+
+	m.entries[task.Name()] = e
+	m.state.track(task.Name(), time.Now())
+
+	return e
+}
+
+// Reschedule атомарно меняет расписание задачи. Новое выражение разбирается до того,
+// как старая запись будет снята, поэтому при ошибке задача продолжает работать по прежнему расписанию.
+// Возвращает время следующего запуска по старому и по новому расписанию
+func (m *Manager) Reschedule(name string, spec string) (oldNext time.Time, newNext time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, exists := m.entries[name]
+	if !exists {
+		return time.Time{}, time.Time{}, ErrSpecifiedTaskNotFound
+	}
+	if e.oneShot {
+		return time.Time{}, time.Time{}, fmt.Errorf("task %s is a one-shot task and has no cron spec", name)
+	}
+
+	schedule, loc, err := m.parseSpec(spec, e.opts.location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parse spec %q: %w", spec, err)
+	}
+
+	now := time.Now()
+	oldNext = m.cron.Entry(e.id).Next
+	if oldNext.IsZero() {
+		if oldSchedule, _, err := m.parseSpec(e.spec, e.opts.location); err == nil {
+			oldNext = oldSchedule.Next(now)
+		}
+	}
+	oldSpec := e.spec
+	m.cron.Remove(e.id)
+	e.spec = spec
+	e.schedule = schedule
+	e.location = loc
+	e.planned = plan(schedule, loc, e.opts)
+	e.id = m.cron.Schedule(e.planned, m.wrap(e))
+
+	newNext = m.cron.Entry(e.id).Next
+	if newNext.IsZero() {
+		newNext = plan(schedule, loc, e.opts).next(now).planned
+	}
+
+	log.Printf("task %s rescheduled from %q to %q, next run %s -> %s",
+		name, oldSpec, spec, oldNext.Format(time.RFC3339), newNext.Format(time.RFC3339))
+
+	return oldNext, newNext, nil
+}
+
 // RemoveTask удаляет задачу по имени
 func (m *Manager) RemoveTask(name string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	id, exists := m.entries[name]
+	e, exists := m.entries[name]
 	if !exists {
+		if m.removeGroup(name) {
+			m.state.forgetPrefix(name + "/")
+			log.Printf("deadline task %s removed", name)
+			return nil
+		}
 		return ErrSpecifiedTaskNotFound
 	}
 
-	m.cron.Remove(id)
+	m.cron.Remove(e.id)
 	delete(m.entries, name)
 
 	log.Printf("task %s removed", name)
@@ -72,19 +212,123 @@ func (m *Manager) RemoveTask(name string) error {
 	return nil
 }
 
-// Start запускает cron
+// Start запускает cron и догоняет запуски, пропущенные задачами, добавленными до старта
 func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return
+	}
+	m.started = true
+
+	now := time.Now()
+	for name, e := range m.entries {
+		if m.catchUp(e, now) || !e.oneShot || !e.schedule.Next(now).IsZero() {
+			continue
+		}
+
+		// время разовой задачи прошло, а догонять ее не нужно
+		m.cron.Remove(e.id)
+		delete(m.entries, name)
+		log.Printf("one-shot task %s expired and removed", name)
+
+		if e.group != "" {
+			m.cleanupDeadline(e.group)
+		}
+	}
+
 	m.cron.Start()
 }
 
-// Stop корректно останавливает cron
+// Stop корректно останавливает cron: новые запуски больше не начинаются, выполняющиеся дожидаются
+// до дедлайна ctx. Если дедлайн наступил раньше, контексты оставшихся запусков отменяются
+// с причиной ErrStopped и возвращается *StopError со списком прерванных задач
 func (m *Manager) Stop(ctx context.Context) error {
-	stopCtx := m.cron.Stop()
+	m.cron.Stop()
+
+	m.runsMu.Lock()
+	m.stopping = true
+	m.runsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.runs.Wait()
+		close(done)
+	}()
 
 	select {
-	case <-stopCtx.Done():
+	case <-done:
+		m.rootCancel()
 		return nil
 	case <-ctx.Done():
-		return ctx.Err()
 	}
+
+	aborted := m.activeTasks()
+	m.rootCancel()
+
+	return &StopError{Tasks: aborted, Err: ctx.Err()}
+}
+
+// parseSpec разбирает выражение в часовом поясе задачи (или Manager, если у задачи он не задан).
+// Явный префикс CRON_TZ= или TZ= в выражении имеет приоритет
+func (m *Manager) parseSpec(spec string, loc *time.Location) (cron.Schedule, *time.Location, error) {
+	if loc == nil {
+		loc = m.location
+	}
+
+	hasTZ := strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=")
+	if hasTZ && !strings.Contains(spec, " ") {
+		return nil, nil, fmt.Errorf("missing schedule after timezone in %q", spec)
+	}
+
+	schedule, err := m.parser.Parse(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if specSchedule, ok := schedule.(*cron.SpecSchedule); ok {
+		if hasTZ {
+			loc = specSchedule.Location
+		} else {
+			specSchedule.Location = loc
+		}
+	}
+
+	return schedule, loc, nil
+}
+
+// taskMiddlewares собирает цепочку оберток задачи: глобальные, встроенные проверки паузы
+// и календаря, затем собственные обертки задачи
+func (m *Manager) taskMiddlewares(o taskOptions) []Middleware {
+	chain := append([]Middleware{}, m.middlewares...)
+	chain = append(chain, m.pauseCheck())
+	if o.businessDaysOnly {
+		chain = append(chain, m.businessDayCheck())
+	}
+	return append(chain, o.middlewares...)
+}
+
+// wrap создает задание для robfig/cron: запуски по расписанию получают в RunInfo.Scheduled
+// время по выражению, соответствующее наступившему запуску, а в RunInfo.Planned - итоговое время
+func (m *Manager) wrap(e *entry) cron.Job {
+	info := RunInfo{
+		Name:     e.task.Name(),
+		Location: e.location,
+	}
+	planned := e.planned
+
+	return cron.FuncJob(func() {
+		runInfo := info
+		now := time.Now()
+		if f, ok := planned.take(now); ok {
+			runInfo.Scheduled = f.scheduled
+			runInfo.Planned = f.planned
+		} else {
+			runInfo.Scheduled = now.Truncate(time.Second)
+			runInfo.Planned = now
+		}
+
+		m.run(e, runInfo)
+	})
 }
@@ -0,0 +1,91 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRescheduleReturnsOldAndNewNext(t *testing.T) {
+	m, err := NewCronManager(WithLocation(testZone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddTask(context.Background(), "0 0 9 * * *", &testTask{name: "daily"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Start()
+	defer m.Stop(context.Background())
+
+	before := time.Now()
+	oldNext, newNext, err := m.Reschedule("daily", "0 30 18 * * *")
+	if err != nil {
+		t.Fatalf("Reschedule() error = %v", err)
+	}
+
+	if oldNext.Before(before) || oldNext.In(testZone).Hour() != 9 || oldNext.Minute() != 0 {
+		t.Errorf("oldNext = %s, want next 09:00 MSK", oldNext)
+	}
+	if newNext.Before(before) || newNext.In(testZone).Hour() != 18 || newNext.Minute() != 30 {
+		t.Errorf("newNext = %s, want next 18:30 MSK", newNext)
+	}
+
+	info, err := m.Get("daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Spec != "0 30 18 * * *" || !info.Next.Equal(newNext) {
+		t.Errorf("Get() = spec %q next %s, want new spec with next %s", info.Spec, info.Next, newNext)
+	}
+}
+
+func TestRescheduleKeepsOldScheduleOnBadSpec(t *testing.T) {
+	m, err := NewCronManager(WithLocation(testZone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddTask(context.Background(), "0 0 9 * * *", &testTask{name: "daily"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Start()
+	defer m.Stop(context.Background())
+
+	before, err := m.Get("daily")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, spec := range []string{"0 0 25 * * *", "not a spec", "TZ=Europe/Moscow"} {
+		if _, _, err := m.Reschedule("daily", spec); err == nil {
+			t.Errorf("Reschedule(%q) error = nil, want parse error", spec)
+		}
+	}
+
+	after, err := m.Get("daily")
+	if err != nil {
+		t.Fatalf("Get() after failed reschedule error = %v", err)
+	}
+	if after.Spec != before.Spec || !after.Next.Equal(before.Next) {
+		t.Errorf("after failed reschedule spec %q next %s, want %q next %s", after.Spec, after.Next, before.Spec, before.Next)
+	}
+}
+
+func TestRescheduleRejectsUnknownAndOneShot(t *testing.T) {
+	m, err := NewCronManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddOneShot(context.Background(), time.Now().Add(time.Hour), &testTask{name: "once"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := m.Reschedule("missing", "0 0 9 * * *"); !errors.Is(err, ErrSpecifiedTaskNotFound) {
+		t.Errorf("Reschedule(missing) error = %v, want ErrSpecifiedTaskNotFound", err)
+	}
+	if _, _, err := m.Reschedule("once", "0 0 9 * * *"); err == nil {
+		t.Error("Reschedule(once) error = nil, want one-shot rejected")
+	}
+}
@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// AddDeadline добавляет серию разовых напоминаний перед дедлайном: по одному на каждый отступ
+// из offsets, повторяющиеся отступы схлопываются. Каждое напоминание - отдельная разовая задача с именем "<name>/<offset>",
+// в RunInfo которой передается Deadline. Отступы, время которых уже прошло, пропускаются.
+// RemoveTask с именем задачи удаляет всю серию, а после последнего напоминания серия
+// убирает за собой и сохраненное состояние
+func (m *Manager) AddDeadline(ctx context.Context, deadline time.Time, offsets []time.Duration, task Task, opts ...TaskOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := task.Name()
+	if _, exists := m.entries[name]; exists || m.hasGroup(name) {
+		return fmt.Errorf("task %s already exists", name)
+	}
+	if len(offsets) == 0 {
+		return fmt.Errorf("deadline task %s: no offsets", name)
+	}
+
+	o := newTaskOptions(opts)
+	now := time.Now()
+
+	sorted := slices.Clone(offsets)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	slices.Reverse(sorted)
+
+	scheduled := 0
+	for _, offset := range sorted {
+		at := deadline.Add(-offset)
+		if m.started && !at.After(now) {
+			continue
+		}
+
+		child := deadlineTask{Task: task, name: fmt.Sprintf("%s/%s", name, offset), deadline: deadline}
+		if _, exists := m.entries[child.Name()]; exists {
+			// уже добавленные напоминания серии снимаются, чтобы не остались сиротами
+			m.removeGroup(name)
+			return fmt.Errorf("task %s already exists", child.Name())
+		}
+
+		if e := m.addOneShot(ctx, at, child, o); e != nil {
+			e.group = name
+			e.deadline = deadline
+			scheduled++
+		}
+	}
+
+	if scheduled == 0 {
+		log.Printf("deadline task %s at %s has nothing left to remind about", name, deadline.Format(time.RFC3339))
+		m.cleanupDeadline(name)
+	}
+
+	return nil
+}
+
+// deadlineTask Напоминание из серии: свое имя и дедлайн в контексте запуска
+type deadlineTask struct {
+	Task
+	name     string
+	deadline time.Time
+}
+
+func (t deadlineTask) Name() string {
+	return t.name
+}
+
+func (t deadlineTask) Work(ctx context.Context) error {
+	info, _ := RunInfoFromContext(ctx)
+	info.Deadline = t.deadline
+	return t.Task.Work(withRunInfo(ctx, info))
+}
+
+// hasGroup сообщает, есть ли задачи из серии name. Вызывается под m.mu
+func (m *Manager) hasGroup(name string) bool {
+	for _, e := range m.entries {
+		if e.group == name {
+			return true
+		}
+	}
+	return false
+}
+
+// removeGroup удаляет все задачи серии. Вызывается под m.mu
+func (m *Manager) removeGroup(name string) bool {
+	removed := false
+	for childName, e := range m.entries {
+		if e.group != name {
+			continue
+		}
+		m.cron.Remove(e.id)
+		delete(m.entries, childName)
+		removed = true
+	}
+	return removed
+}
+
+// cleanupDeadline забывает состояние серии, когда в ней не осталось напоминаний. Вызывается под m.mu
+func (m *Manager) cleanupDeadline(name string) {
+	if m.hasGroup(name) {
+		return
+	}
+
+	m.state.forgetPrefix(name + "/")
+	log.Printf("deadline task %s completed, state cleaned up", name)
+}
@@ -0,0 +1,97 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAddDeadlineCollapsesDuplicateOffsets(t *testing.T) {
+	m, err := NewCronManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Hour)
+	offsets := []time.Duration{10 * time.Minute, 10 * time.Minute}
+	if err := m.AddDeadline(context.Background(), deadline, offsets, &testTask{name: "report"}); err != nil {
+		t.Fatalf("AddDeadline() error = %v", err)
+	}
+
+	if tasks := m.List(); len(tasks) != 1 || tasks[0].Name != "report/10m0s" {
+		t.Fatalf("List() = %+v, want single report/10m0s", tasks)
+	}
+
+	// повторное применение той же задачи, как при перечитывании конфига, проходит
+	if err := m.RemoveTask("report"); err != nil {
+		t.Fatalf("RemoveTask() error = %v", err)
+	}
+	if err := m.AddDeadline(context.Background(), deadline, offsets, &testTask{name: "report"}); err != nil {
+		t.Fatalf("AddDeadline() after remove error = %v", err)
+	}
+}
+
+func TestAddDeadlineRollsBackOnConflict(t *testing.T) {
+	m, err := NewCronManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.AddTask(context.Background(), "0 0 0 1 1 *", &testTask{name: "report/20m0s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Hour)
+	offsets := []time.Duration{30 * time.Minute, 20 * time.Minute}
+	if err := m.AddDeadline(context.Background(), deadline, offsets, &testTask{name: "report"}); err == nil {
+		t.Fatal("AddDeadline() error = nil, want conflict")
+	}
+
+	if _, err := m.Get("report/30m0s"); !errors.Is(err, ErrSpecifiedTaskNotFound) {
+		t.Errorf("Get(report/30m0s) error = %v, want ErrSpecifiedTaskNotFound", err)
+	}
+	if _, err := m.Get("report/20m0s"); err != nil {
+		t.Errorf("Get(report/20m0s) error = %v, want the unrelated task kept", err)
+	}
+}
+
+func TestControlResolvesDeadlineGroup(t *testing.T) {
+	m, err := NewCronManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Hour)
+	offsets := []time.Duration{30 * time.Minute, 10 * time.Minute}
+	if err := m.AddDeadline(context.Background(), deadline, offsets, &testTask{name: "report"}); err != nil {
+		t.Fatal(err)
+	}
+
+	until := time.Now().Add(2 * time.Hour)
+	if err := m.Pause("report", until); err != nil {
+		t.Fatalf("Pause(report) error = %v", err)
+	}
+	for _, task := range m.List() {
+		if !task.Paused || !task.PausedUntil.Equal(until) {
+			t.Errorf("%s: Paused = %v until %s, want paused until %s", task.Name, task.Paused, task.PausedUntil, until)
+		}
+	}
+
+	info, err := m.Get("report")
+	if err != nil {
+		t.Fatalf("Get(report) error = %v", err)
+	}
+	if info.Name != "report/30m0s" || info.Group != "report" {
+		t.Errorf("Get(report) = %s in group %q, want nearest reminder report/30m0s", info.Name, info.Group)
+	}
+
+	if err := m.Resume("report"); err != nil {
+		t.Fatalf("Resume(report) error = %v", err)
+	}
+	for _, task := range m.List() {
+		if task.Paused {
+			t.Errorf("%s: still paused after Resume", task.Name)
+		}
+	}
+}
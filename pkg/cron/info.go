@@ -0,0 +1,134 @@
+package cron
+
+import (
+	"sort"
+	"time"
+)
+
+// TaskInfo Снимок состояния задачи для внешних потребителей
+type TaskInfo struct {
+	Name     string
+	Spec     string
+	Location *time.Location
+	// Next Запланированное время следующего запуска с учетом окна отправки и джиттера
+	Next time.Time
+	// Prev Время предыдущего запуска по расписанию
+	Prev    time.Time
+	Running bool
+
+	// LastRun Время начала последнего выполнения Work
+	LastRun time.Time
+	// LastError Ошибка последнего выполнения, nil при успехе
+	LastError    error
+	LastDuration time.Duration
+	// LastSuccess Время начала последнего успешного выполнения, переживает рестарт
+	LastSuccess time.Time
+
+	Skipped  int64
+	Overruns int64
+
+	Jitter     time.Duration
+	SendWindow *SendWindow
+
+	// Group Серия напоминаний о дедлайне Deadline, к которой относится задача
+	Group    string
+	Deadline time.Time
+
+	Paused bool
+	// PausedUntil Когда пауза снимется сама, нулевое - только через Resume
+	PausedUntil time.Time
+}
+
+// RunResult Итог выполнения задачи
+type RunResult struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	// Err Ошибка выполнения, nil при успехе
+	Err error
+}
+
+// List возвращает сведения обо всех задачах, отсортированные по имени
+func (m *Manager) List() []TaskInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]TaskInfo, 0, len(m.entries))
+	for _, e := range m.entries {
+		infos = append(infos, m.info(e))
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+// Get возвращает сведения об одной задаче. Для серии напоминаний о дедлайне - о ближайшем напоминании
+func (m *Manager) Get(name string) (TaskInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := m.resolveLocked(name)
+	if len(entries) == 0 {
+		return TaskInfo{}, ErrSpecifiedTaskNotFound
+	}
+
+	return m.info(entries[0]), nil
+}
+
+func (m *Manager) info(e *entry) TaskInfo {
+	name := e.task.Name()
+	cronEntry := m.cron.Entry(e.id)
+
+	info := TaskInfo{
+		Name:       name,
+		Spec:       e.spec,
+		Location:   e.location,
+		Next:       m.nextLocked(e),
+		Prev:       cronEntry.Prev,
+		Running:    m.isActive(name),
+		Skipped:    e.skipped.Load(),
+		Overruns:   e.overruns.Load(),
+		Jitter:     e.opts.jitter,
+		SendWindow: e.opts.window,
+		Group:      e.group,
+		Deadline:   e.deadline,
+	}
+
+	last := e.result()
+	info.LastRun = last.StartedAt
+	info.LastDuration = last.Duration
+	info.LastError = last.Err
+	ts := m.state.get(name)
+	info.LastSuccess = ts.LastRun
+	if ts.pausedAt(time.Now()) {
+		info.Paused = true
+		info.PausedUntil = ts.PausedUntil
+	}
+
+	return info
+}
+
+func (m *Manager) isActive(name string) bool {
+	m.runsMu.Lock()
+	defer m.runsMu.Unlock()
+
+	return m.active[name] > 0
+}
+
+// nextLocked Запланированное время следующего запуска. Вызывается под m.mu
+func (m *Manager) nextLocked(e *entry) time.Time {
+	next := m.cron.Entry(e.id).Next
+	if next.IsZero() {
+		next = plan(e.schedule, e.location, e.opts).next(time.Now()).planned
+	}
+	return next
+}
+
+func (e *entry) result() RunResult {
+	e.resultMu.Lock()
+	defer e.resultMu.Unlock()
+
+	return e.lastResult
+}
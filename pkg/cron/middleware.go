@@ -0,0 +1,116 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// ErrSkipped возвращается обертками, решившими не выполнять задачу. Такой запуск не считается
+// ни успешным, ни упавшим
+var ErrSkipped = errors.New("task skipped")
+
+// Middleware оборачивает задачу, добавляя сквозное поведение вокруг Work
+type Middleware func(next Task) Task
+
+// TaskFunc превращает функцию в Task с заданным именем. Удобно для написания оберток
+func TaskFunc(name string, work func(ctx context.Context) error) Task {
+	return taskFunc{name: name, work: work}
+}
+
+type taskFunc struct {
+	name string
+	work func(ctx context.Context) error
+}
+
+func (t taskFunc) Name() string                   { return t.name }
+func (t taskFunc) Work(ctx context.Context) error { return t.work(ctx) }
+
+// Chain применяет обертки так, что первая оказывается самой внешней
+func Chain(task Task, middlewares ...Middleware) Task {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		task = middlewares[i](task)
+	}
+	return task
+}
+
+// DefaultMiddleware Обертки Manager по умолчанию: восстановление после паники и логирование результата
+func DefaultMiddleware() []Middleware {
+	return []Middleware{Recover(), Logging(nil)}
+}
+
+// Recover превращает панику в Work в ошибку со стеком, не роняя процесс
+func Recover() Middleware {
+	return func(next Task) Task {
+		return TaskFunc(next.Name(), func(ctx context.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+				}
+			}()
+
+			return next.Work(ctx)
+		})
+	}
+}
+
+// Timing сообщает observe длительность и результат каждого запуска, например для метрик
+func Timing(observe func(name string, duration time.Duration, err error)) Middleware {
+	return func(next Task) Task {
+		return TaskFunc(next.Name(), func(ctx context.Context) error {
+			startedAt := time.Now()
+			err := next.Work(ctx)
+			observe(next.Name(), time.Since(startedAt), err)
+			return err
+		})
+	}
+}
+
+// Logging пишет структурированную запись о результате запуска. nil означает slog.Default()
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Task) Task {
+		return TaskFunc(next.Name(), func(ctx context.Context) error {
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+
+			attrs := []any{slog.String("task", next.Name())}
+			if info, ok := RunInfoFromContext(ctx); ok && info.Manual {
+				attrs = append(attrs, slog.Bool("manual", true))
+			}
+
+			startedAt := time.Now()
+			err := next.Work(ctx)
+			attrs = append(attrs, slog.Duration("duration", time.Since(startedAt)))
+
+			switch {
+			case errors.Is(err, ErrSkipped):
+				l.InfoContext(ctx, "task skipped", append(attrs, slog.String("reason", strings.TrimPrefix(err.Error(), ErrSkipped.Error()+": ")))...)
+			case err != nil:
+				l.ErrorContext(ctx, "task failed", append(attrs, slog.Any("error", err))...)
+			default:
+				l.InfoContext(ctx, "task completed successfully", attrs...)
+			}
+
+			return err
+		})
+	}
+}
+
+// SkipIf пропускает запуск, если cond вернул true. Причина попадает в ошибку, обернутую в ErrSkipped
+func SkipIf(cond func(ctx context.Context) (skip bool, reason string)) Middleware {
+	return func(next Task) Task {
+		return TaskFunc(next.Name(), func(ctx context.Context) error {
+			if skip, reason := cond(ctx); skip {
+				return fmt.Errorf("%w: %s", ErrSkipped, reason)
+			}
+
+			return next.Work(ctx)
+		})
+	}
+}
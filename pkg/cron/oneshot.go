@@ -0,0 +1,95 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// onceSchedule Расписание с единственным запуском в момент at
+type onceSchedule struct {
+	at time.Time
+}
+
+func (s onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+// AddOneShot добавляет разовую задачу, которая выполнится в момент at и удалит себя.
+// Время запуска и факт успешного выполнения сохраняются в состоянии, поэтому после рестарта
+// уже выполненная задача не повторится, а пропущенная догоняется по политике WithCatchUp.
+// Если в момент at задача стоит на паузе или день нерабочий, она снимается без выполнения
+func (m *Manager) AddOneShot(ctx context.Context, at time.Time, task Task, opts ...TaskOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.entries[task.Name()]; exists {
+		return fmt.Errorf("task %s already exists", task.Name())
+	}
+
+	if m.started && !at.After(time.Now()) {
+		return fmt.Errorf("one-shot task %s: time %s is in the past", task.Name(), at.Format(time.RFC3339))
+	}
+
+	m.addOneShot(ctx, at, task, newTaskOptions(opts))
+
+	return nil
+}
+
+// addOneShot регистрирует разовую задачу, если она еще не выполнялась. Вызывается под m.mu
+func (m *Manager) addOneShot(ctx context.Context, at time.Time, task Task, o taskOptions) *entry {
+	name := task.Name()
+
+	if m.state.oneShotFired(name, at) {
+		log.Printf("one-shot task %s at %s already fired, not scheduling", name, at.Format(time.RFC3339))
+		return nil
+	}
+
+	loc := o.location
+	if loc == nil {
+		loc = m.location
+	}
+
+	e := m.addEntry(ctx, "@at "+at.Format(time.RFC3339), onceSchedule{at: at}, loc, task, o)
+	e.oneShot = true
+	m.state.setOneShot(name, at)
+
+	log.Printf("one-shot task %s added for %s", name, at.In(loc).Format(time.RFC3339))
+	return e
+}
+
+// finishOneShot удаляет разовую задачу после ее запуска по расписанию, если ее не успели заменить.
+// Пропущенный паузой или календарем запуск тоже снимает задачу: второго срабатывания у нее не будет,
+// но отметка о выполнении не ставится
+func (m *Manager) finishOneShot(e *entry, err error) {
+	name := e.task.Name()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err == nil {
+		m.state.setOneShotFired(name, time.Now())
+	}
+
+	if m.entries[name] != e {
+		return
+	}
+
+	m.cron.Remove(e.id)
+	delete(m.entries, name)
+
+	if errors.Is(err, ErrSkipped) {
+		log.Printf("one-shot task %s removed without running: %v", name, err)
+	} else {
+		log.Printf("one-shot task %s finished and removed", name)
+	}
+
+	if e.group != "" {
+		m.cleanupDeadline(e.group)
+	}
+}
@@ -0,0 +1,101 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testTask Задача для тестов, считает свои запуски
+type testTask struct {
+	name  string
+	runs  atomic.Int64
+	infos chan RunInfo
+}
+
+func (t *testTask) Name() string {
+	return t.name
+}
+
+func (t *testTask) Work(ctx context.Context) error {
+	t.runs.Add(1)
+	if t.infos != nil {
+		info, _ := RunInfoFromContext(ctx)
+		t.infos <- info
+	}
+	return nil
+}
+
+func TestSkippedOneShotIsRemoved(t *testing.T) {
+	m, err := NewCronManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := &testTask{name: "once"}
+	at := time.Now().Truncate(time.Second).Add(2 * time.Second)
+	if err := m.AddOneShot(context.Background(), at, task); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Pause("once", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Start()
+	time.Sleep(time.Until(at) + 500*time.Millisecond)
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs := task.runs.Load(); runs != 0 {
+		t.Errorf("paused one-shot ran %d times", runs)
+	}
+	if _, err := m.Get("once"); !errors.Is(err, ErrSpecifiedTaskNotFound) {
+		t.Errorf("Get(once) error = %v, want skipped one-shot removed", err)
+	}
+	if m.state.oneShotFired("once", at) {
+		t.Error("skipped one-shot marked as fired")
+	}
+}
+
+func TestTriggerKeepsScheduledOneShot(t *testing.T) {
+	m, err := NewCronManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task := &testTask{name: "once", infos: make(chan RunInfo, 1)}
+	at := time.Now().Add(time.Hour)
+	if err := m.AddOneShot(context.Background(), at, task); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Start()
+	if err := m.Trigger("once"); err != nil {
+		t.Fatalf("Trigger(once) error = %v", err)
+	}
+	select {
+	case info := <-task.infos:
+		if !info.Manual {
+			t.Error("RunInfo.Manual = false for a triggered run")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("triggered run did not start")
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := m.Get("once")
+	if err != nil {
+		t.Fatalf("Get(once) error = %v, want scheduled run kept", err)
+	}
+	if !info.Next.Equal(at) {
+		t.Errorf("Next = %s, want %s", info.Next, at)
+	}
+	if m.state.oneShotFired("once", at) {
+		t.Error("manual run marked the one-shot as fired")
+	}
+}
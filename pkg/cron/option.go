@@ -0,0 +1,134 @@
+package cron
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Option настраивает Manager
+type Option func(m *Manager)
+
+// WithLocation задает часовой пояс по умолчанию для всех задач
+func WithLocation(loc *time.Location) Option {
+	return func(m *Manager) {
+		if loc == nil {
+			return
+		}
+		m.location = loc
+		m.cronOpts = append(m.cronOpts, cron.WithLocation(loc))
+	}
+}
+
+// WithCalendar задает производственный календарь для задач с WithBusinessDaysOnly
+func WithCalendar(calendar *Calendar) Option {
+	return func(m *Manager) {
+		m.calendar = calendar
+	}
+}
+
+// WithStateFile задает файл, в котором хранится состояние задач между рестартами
+func WithStateFile(path string) Option {
+	return func(m *Manager) {
+		m.statePath = path
+	}
+}
+
+// WithCatchUpWindow задает окно по умолчанию, в пределах которого пропущенный запуск еще догоняется
+func WithCatchUpWindow(window time.Duration) Option {
+	return func(m *Manager) {
+		m.catchUpWindow = window
+	}
+}
+
+// WithMiddleware заменяет глобальную цепочку оберток (по умолчанию DefaultMiddleware).
+// Глобальные обертки внешние по отношению к обертками задачи
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(m *Manager) {
+		m.middlewares = middlewares
+	}
+}
+
+// TaskOption настраивает отдельную задачу
+type TaskOption func(o *taskOptions)
+
+type taskOptions struct {
+	location         *time.Location
+	businessDaysOnly bool
+	catchUpPolicy    CatchUpPolicy
+	catchUpWindow    time.Duration
+	overlapPolicy    OverlapPolicy
+	timeout          time.Duration
+	middlewares      []Middleware
+	jitter           time.Duration
+	window           *SendWindow
+	windowPolicy     WindowPolicy
+}
+
+// WithTaskLocation задает часовой пояс задачи. Префикс CRON_TZ= в самом выражении имеет приоритет
+func WithTaskLocation(loc *time.Location) TaskOption {
+	return func(o *taskOptions) {
+		o.location = loc
+	}
+}
+
+// WithBusinessDaysOnly пропускает запуски в выходные и праздники по календарю Manager
+func WithBusinessDaysOnly() TaskOption {
+	return func(o *taskOptions) {
+		o.businessDaysOnly = true
+	}
+}
+
+// WithCatchUp задает политику для запусков, пропущенных во время простоя.
+// Нулевое окно означает окно Manager по умолчанию
+func WithCatchUp(policy CatchUpPolicy, window time.Duration) TaskOption {
+	return func(o *taskOptions) {
+		o.catchUpPolicy = policy
+		o.catchUpWindow = window
+	}
+}
+
+// WithOverlapPolicy задает поведение при наложении запусков, по умолчанию OverlapSkip
+func WithOverlapPolicy(policy OverlapPolicy) TaskOption {
+	return func(o *taskOptions) {
+		o.overlapPolicy = policy
+	}
+}
+
+// WithTimeout ограничивает длительность одного запуска: Work получает контекст с дедлайном
+func WithTimeout(timeout time.Duration) TaskOption {
+	return func(o *taskOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithTaskMiddleware добавляет обертки только для этой задачи
+func WithTaskMiddleware(middlewares ...Middleware) TaskOption {
+	return func(o *taskOptions) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// WithJitter сдвигает каждый запуск на случайное время от 0 до max после времени по расписанию
+func WithJitter(max time.Duration) TaskOption {
+	return func(o *taskOptions) {
+		o.jitter = max
+	}
+}
+
+// WithSendWindow ограничивает запуски окном времени суток в часовом поясе задачи.
+// Запуски вне окна переносятся на его начало или отбрасываются согласно policy
+func WithSendWindow(window SendWindow, policy WindowPolicy) TaskOption {
+	return func(o *taskOptions) {
+		o.window = &window
+		o.windowPolicy = policy
+	}
+}
+
+func newTaskOptions(opts []TaskOption) taskOptions {
+	var o taskOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
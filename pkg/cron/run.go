@@ -0,0 +1,232 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OverlapPolicy Что делать, если пора запускать задачу, а предыдущий запуск еще не закончился
+type OverlapPolicy int
+
+const (
+	// OverlapSkip новый запуск пропускается
+	OverlapSkip OverlapPolicy = iota
+	// OverlapDelay новый запуск ждет окончания предыдущего
+	OverlapDelay
+	// OverlapAllow запуски выполняются параллельно
+	OverlapAllow
+)
+
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapDelay:
+		return "delay"
+	case OverlapAllow:
+		return "allow"
+	default:
+		return "skip"
+	}
+}
+
+// ParseOverlapPolicy разбирает политику из конфига. Пустая строка означает skip
+func ParseOverlapPolicy(s string) (OverlapPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "skip":
+		return OverlapSkip, nil
+	case "delay", "queue":
+		return OverlapDelay, nil
+	case "allow":
+		return OverlapAllow, nil
+	default:
+		return OverlapSkip, fmt.Errorf("unknown overlap policy %q", s)
+	}
+}
+
+// ErrStopped Причина отмены контекста запуска, прерванного Stop по истечении дедлайна.
+// Задача отличает ее от собственной отмены через context.Cause
+var ErrStopped = errors.New("cron manager stopped")
+
+// StopError Stop не дождался завершения запусков, перечисленные задачи были прерваны
+type StopError struct {
+	Tasks []string
+	Err   error
+}
+
+func (e *StopError) Error() string {
+	return fmt.Sprintf("cron stopped with aborted tasks [%s]: %v", strings.Join(e.Tasks, ", "), e.Err)
+}
+
+func (e *StopError) Unwrap() error {
+	return e.Err
+}
+
+// run выполняет один запуск задачи с учетом политики наложения и лимита времени
+func (m *Manager) run(e *entry, info RunInfo) {
+	if !m.beginRun(info.Name) {
+		log.Printf("task %s skipped: manager is stopping", info.Name)
+		return
+	}
+	defer m.endRun(info.Name)
+
+	switch e.opts.overlapPolicy {
+	case OverlapSkip:
+		select {
+		case e.running <- struct{}{}:
+		default:
+			e.skipped.Add(1)
+			log.Printf("task %s skipped: previous run is still in progress", info.Name)
+			if e.oneShot && !info.Manual {
+				m.finishOneShot(e, fmt.Errorf("%w: previous run is still in progress", ErrSkipped))
+			}
+			return
+		}
+		defer func() { <-e.running }()
+	case OverlapDelay:
+		select {
+		case e.running <- struct{}{}:
+		default:
+			log.Printf("task %s delayed: previous run is still in progress", info.Name)
+			select {
+			case e.running <- struct{}{}:
+			case <-m.rootCtx.Done():
+				return
+			}
+		}
+		defer func() { <-e.running }()
+	}
+
+	info.RunCount = m.state.get(info.Name).Runs + 1
+	info.Previous = e.result()
+	m.mu.Lock()
+	info.Next = m.nextLocked(e)
+	m.mu.Unlock()
+
+	ctx, cancelRun := context.WithCancelCause(withRunInfo(e.ctx, info))
+	defer cancelRun(nil)
+	stopAbort := context.AfterFunc(m.rootCtx, func() { cancelRun(ErrStopped) })
+	defer stopAbort()
+
+	if e.opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.opts.timeout)
+		defer cancel()
+	}
+
+	startedAt := time.Now()
+	err := e.wrapped.Work(ctx)
+	duration := time.Since(startedAt)
+
+	// разовая задача снимается после своего срабатывания по расписанию, даже пропущенного:
+	// больше она не сработает. Запуск вручную оставляет ее ждать своего времени
+	if e.oneShot && !info.Manual {
+		defer m.finishOneShot(e, err)
+	}
+
+	if errors.Is(err, ErrSkipped) {
+		return
+	}
+
+	e.resultMu.Lock()
+	e.lastResult = RunResult{StartedAt: startedAt, Duration: duration, Err: err}
+	e.resultMu.Unlock()
+
+	if e.opts.timeout > 0 && (duration > e.opts.timeout || errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+		e.overruns.Add(1)
+		log.Printf("task %s overran its timeout: took %s, limit %s", info.Name, duration, e.opts.timeout)
+	}
+
+	if err != nil {
+		return
+	}
+
+	m.state.setLastRun(info.Name, startedAt)
+}
+
+func (m *Manager) beginRun(name string) bool {
+	m.runsMu.Lock()
+	defer m.runsMu.Unlock()
+
+	if m.stopping {
+		return false
+	}
+
+	m.runs.Add(1)
+	m.active[name]++
+	return true
+}
+
+func (m *Manager) endRun(name string) {
+	m.runsMu.Lock()
+	defer m.runsMu.Unlock()
+
+	m.active[name]--
+	if m.active[name] <= 0 {
+		delete(m.active, name)
+	}
+	m.runs.Done()
+}
+
+func (m *Manager) activeTasks() []string {
+	m.runsMu.Lock()
+	defer m.runsMu.Unlock()
+
+	names := make([]string, 0, len(m.active))
+	for name := range m.active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// pauseCheck пропускает плановые запуски задачи, стоящей на паузе
+func (m *Manager) pauseCheck() Middleware {
+	return SkipIf(func(ctx context.Context) (bool, string) {
+		info, _ := RunInfoFromContext(ctx)
+		if info.Manual {
+			return false, ""
+		}
+
+		ts := m.state.get(info.Name)
+		if !ts.pausedAt(time.Now()) {
+			return false, ""
+		}
+		if ts.PausedUntil.IsZero() {
+			return true, "paused"
+		}
+		return true, "paused until " + ts.PausedUntil.Format(time.RFC3339)
+	})
+}
+
+// businessDayCheck пропускает плановые запуски в нерабочие дни по календарю Manager.
+// Рабочими должны быть и день срабатывания по расписанию, и день, когда сообщение уходит:
+// окно отправки переносит пятничный вечерний запуск на утро субботы, а догоняющий запуск
+// после простоя может прийтись на выходной
+func (m *Manager) businessDayCheck() Middleware {
+	return SkipIf(func(ctx context.Context) (bool, string) {
+		info, _ := RunInfoFromContext(ctx)
+		if info.Manual {
+			return false, ""
+		}
+
+		planned := info.Planned
+		if planned.IsZero() {
+			planned = time.Now()
+		}
+
+		for _, day := range []time.Time{info.Scheduled, planned} {
+			if day.IsZero() {
+				continue
+			}
+			if ok, reason := m.calendar.IsBusinessDay(day.In(info.Location)); !ok {
+				return true, reason
+			}
+		}
+		return false, ""
+	})
+}
@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBusinessDayCheckJudgesSendDay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.yaml")
+	if err := os.WriteFile(path, []byte("holidays:\n  - date: \"2025-03-13\"\n    name: test holiday\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	calendar, err := LoadCalendar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewCronManager(WithLocation(testZone), WithCalendar(calendar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2025-03-03 понедельник
+	day := func(d, hour int) time.Time {
+		return time.Date(2025, time.March, d, hour, 0, 0, 0, testZone)
+	}
+
+	tests := []struct {
+		name      string
+		scheduled time.Time
+		planned   time.Time
+		manual    bool
+		wantSkip  bool
+	}{
+		{name: "weekday", scheduled: day(7, 10), planned: day(7, 10)},
+		{name: "friday evening deferred to saturday", scheduled: day(7, 23), planned: day(8, 9), wantSkip: true},
+		{name: "sunday evening deferred to monday", scheduled: day(9, 23), planned: day(10, 9), wantSkip: true},
+		{name: "deferred to holiday morning", scheduled: day(12, 23), planned: day(13, 9), wantSkip: true},
+		{name: "jitter past midnight into friday", scheduled: day(6, 23), planned: day(7, 0)},
+		{name: "manual on saturday", scheduled: day(8, 12), planned: day(8, 12), manual: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &testTask{name: "daily"}
+			ctx := withRunInfo(context.Background(), RunInfo{
+				Name:      "daily",
+				Location:  testZone,
+				Scheduled: tt.scheduled,
+				Planned:   tt.planned,
+				Manual:    tt.manual,
+			})
+
+			err := Chain(task, m.businessDayCheck()).Work(ctx)
+			if skipped := errors.Is(err, ErrSkipped); skipped != tt.wantSkip {
+				t.Errorf("skipped = %v (%v), want %v", skipped, err, tt.wantSkip)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package cron
+
+import (
+	"context"
+	"time"
+)
+
+type runInfoKey struct{}
+
+// RunInfo Сведения о текущем запуске задачи, доступные из Work через контекст
+type RunInfo struct {
+	// Name Имя задачи
+	Name string
+	// Location Часовой пояс, в котором задача планируется
+	Location *time.Location
+	// Scheduled Время запуска по расписанию без учета окна и джиттера. Одинаково для повторов
+	// и догоняющих запусков одного и того же срабатывания; для Trigger - момент вызова
+	Scheduled time.Time
+	// Planned Время, на которое запуск был запланирован с учетом окна отправки и джиттера,
+	// то есть когда сообщение уходит на самом деле
+	Planned time.Time
+	// Manual Запуск вызван через Trigger, а не по расписанию
+	Manual bool
+	// Deadline Дедлайн, о котором напоминает задача из серии AddDeadline
+	Deadline time.Time
+	// RunCount Порядковый номер запуска: число успешных выполнений до него плюс один, переживает рестарт
+	RunCount int64
+	// Previous Итог предыдущего выполнения, нулевой до первого запуска после старта
+	Previous RunResult
+	// Next Запланированное время следующего запуска, нулевое у разовых задач
+	Next time.Time
+}
+
+// RunInfoFromContext достает сведения о запуске, положенные Manager в контекст задачи
+func RunInfoFromContext(ctx context.Context) (RunInfo, bool) {
+	info, ok := ctx.Value(runInfoKey{}).(RunInfo)
+	return info, ok
+}
+
+func withRunInfo(ctx context.Context, info RunInfo) context.Context {
+	return context.WithValue(ctx, runInfoKey{}, info)
+}
@@ -0,0 +1,164 @@
+package cron
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// maxDropLookahead Сколько запусков вперед перебирать в поисках попадающего в окно
+	maxDropLookahead = 10000
+	// maxPendingFirings Сколько запланированных запусков помнить для сопоставления с выполнением
+	maxPendingFirings = 16
+)
+
+// firing Запланированный запуск: время по cron-выражению и итоговое время с окном и джиттером
+type firing struct {
+	scheduled time.Time
+	planned   time.Time
+}
+
+// plannedSchedule Расписание с окном отправки и случайным сдвигом поверх cron-выражения.
+// Entry.Next в robfig/cron, а значит и TaskInfo.Next, показывают уже итоговое время.
+// Кроме того, расписание запоминает, какому времени по выражению соответствует каждый
+// запланированный запуск, чтобы задача получила в RunInfo.Scheduled детерминированное время
+type plannedSchedule struct {
+	base     cron.Schedule
+	location *time.Location
+	jitter   time.Duration
+	window   *SendWindow
+	policy   WindowPolicy
+
+	mu      sync.Mutex
+	pending []firing
+}
+
+func plan(base cron.Schedule, loc *time.Location, o taskOptions) *plannedSchedule {
+	return &plannedSchedule{
+		base:     base,
+		location: loc,
+		jitter:   o.jitter,
+		window:   o.window,
+		policy:   o.windowPolicy,
+	}
+}
+
+func (s *plannedSchedule) Next(t time.Time) time.Time {
+	f := s.next(t)
+	if f.planned.IsZero() {
+		return f.planned
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, f)
+	if len(s.pending) > maxPendingFirings {
+		s.pending = s.pending[len(s.pending)-maxPendingFirings:]
+	}
+
+	return f.planned
+}
+
+// take возвращает самый ранний запланированный запуск, который уже должен был наступить к now
+func (s *plannedSchedule) take(now time.Time) (firing, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.pending {
+		if f.planned.After(now) {
+			break
+		}
+		s.pending = s.pending[i+1:]
+		return f, true
+	}
+
+	return firing{}, false
+}
+
+func (s *plannedSchedule) next(t time.Time) firing {
+	next := s.base.Next(t)
+	if next.IsZero() {
+		return firing{}
+	}
+
+	if s.window != nil && s.policy == WindowDrop && !s.window.contains(next.In(s.location)) {
+		next = s.nextInWindow(next)
+		if next.IsZero() {
+			return firing{}
+		}
+	}
+
+	return s.at(next)
+}
+
+// at Запуск срабатывания scheduled с окном и джиттером. Нулевой firing означает, что окно
+// с политикой drop отбрасывает срабатывание
+func (s *plannedSchedule) at(scheduled time.Time) firing {
+	f := firing{scheduled: scheduled, planned: scheduled}
+
+	if s.window != nil {
+		local := scheduled.In(s.location)
+		if !s.window.contains(local) {
+			if s.policy == WindowDrop {
+				return firing{}
+			}
+			local = s.window.nextStart(local)
+		}
+		f.planned = local
+	}
+
+	f.planned = s.jittered(f.planned)
+	return f
+}
+
+// catchUp Догоняющий запуск срабатывания scheduled, пропущенного до now. Окно применяется к самому
+// срабатыванию, как к обычному запуску, и к моменту отправки: если его время уже прошло, запуск
+// уходит сейчас, а вне окна - переносится на начало ближайшего окна или отбрасывается по политике
+func (s *plannedSchedule) catchUp(scheduled time.Time, now time.Time) firing {
+	f := s.at(scheduled)
+	if f.planned.IsZero() || !f.planned.Before(now) {
+		return f
+	}
+
+	f.planned = now
+	if s.window != nil {
+		local := now.In(s.location)
+		if !s.window.contains(local) {
+			if s.policy == WindowDrop {
+				return firing{}
+			}
+			f.planned = s.jittered(s.window.nextStart(local))
+		}
+	}
+
+	return f
+}
+
+// jittered сдвигает t на случайное время в пределах джиттера, не выходя за конец окна
+func (s *plannedSchedule) jittered(t time.Time) time.Time {
+	if s.jitter <= 0 {
+		return t
+	}
+
+	jitter := time.Duration(rand.Int64N(int64(s.jitter) + 1))
+	if s.window != nil {
+		if room := s.window.end(t.In(s.location)).Sub(t) - time.Second; jitter > room {
+			jitter = max(room, 0)
+		}
+	}
+	return t.Add(jitter)
+}
+
+func (s *plannedSchedule) nextInWindow(next time.Time) time.Time {
+	for range maxDropLookahead {
+		if next.IsZero() || s.window.contains(next.In(s.location)) {
+			return next.In(s.location)
+		}
+		next = s.base.Next(next)
+	}
+	return time.Time{}
+}
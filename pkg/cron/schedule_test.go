@@ -0,0 +1,249 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+var testZone = time.FixedZone("MSK", 3*60*60)
+
+func at(hour, minute, second int) time.Time {
+	return time.Date(2025, time.March, 3, hour, minute, second, 0, testZone)
+}
+
+func mustWindow(t *testing.T, s string) *SendWindow {
+	t.Helper()
+
+	w, err := ParseSendWindow(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &w
+}
+
+func mustPlan(t *testing.T, spec string, o taskOptions) *plannedSchedule {
+	t.Helper()
+
+	m, err := NewCronManager(WithLocation(testZone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule, loc, err := m.parseSpec(spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan(schedule, loc, o)
+}
+
+func TestSendWindowContains(t *testing.T) {
+	tests := []struct {
+		window string
+		t      time.Time
+		want   bool
+	}{
+		{"09:00-19:00", at(9, 0, 0), true},
+		{"09:00-19:00", at(18, 59, 59), true},
+		{"09:00-19:00", at(19, 0, 0), false},
+		{"09:00-19:00", at(8, 59, 59), false},
+		{"22:00-06:00", at(22, 0, 0), true},
+		{"22:00-06:00", at(23, 59, 59), true},
+		{"22:00-06:00", at(0, 0, 0), true},
+		{"22:00-06:00", at(5, 59, 59), true},
+		{"22:00-06:00", at(6, 0, 0), false},
+		{"22:00-06:00", at(12, 0, 0), false},
+	}
+
+	for _, tt := range tests {
+		if got := mustWindow(t, tt.window).contains(tt.t); got != tt.want {
+			t.Errorf("%s contains %s = %v, want %v", tt.window, tt.t.Format("15:04:05"), got, tt.want)
+		}
+	}
+}
+
+func TestSendWindowBoundsPastMidnight(t *testing.T) {
+	w := mustWindow(t, "22:00-06:00")
+
+	tests := []struct {
+		name string
+		got  time.Time
+		want time.Time
+	}{
+		{"start later today", w.nextStart(at(12, 0, 0)), at(22, 0, 0)},
+		{"start tomorrow", w.nextStart(at(22, 0, 1)), at(22, 0, 0).AddDate(0, 0, 1)},
+		{"end after midnight", w.end(at(23, 0, 0)), at(6, 0, 0).AddDate(0, 0, 1)},
+		{"end this morning", w.end(at(5, 0, 0)), at(6, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		if !tt.got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestPlannedScheduleWindowPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          string
+		window        string
+		policy        WindowPolicy
+		from          time.Time
+		wantScheduled time.Time
+		wantPlanned   time.Time
+	}{
+		{
+			name: "defer to window start", spec: "0 0 3 * * *", window: "09:00-19:00", policy: WindowDefer,
+			from: at(0, 0, 0), wantScheduled: at(3, 0, 0), wantPlanned: at(9, 0, 0),
+		},
+		{
+			name: "defer into window past midnight", spec: "0 0 12 * * *", window: "22:00-06:00", policy: WindowDefer,
+			from: at(0, 0, 0), wantScheduled: at(12, 0, 0), wantPlanned: at(22, 0, 0),
+		},
+		{
+			name: "drop to next run inside window past midnight", spec: "0 0 * * * *", window: "22:00-06:00", policy: WindowDrop,
+			from: at(12, 0, 0), wantScheduled: at(22, 0, 0), wantPlanned: at(22, 0, 0),
+		},
+		{
+			name: "inside window untouched", spec: "0 30 10 * * *", window: "09:00-19:00", policy: WindowDrop,
+			from: at(0, 0, 0), wantScheduled: at(10, 30, 0), wantPlanned: at(10, 30, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustPlan(t, tt.spec, taskOptions{window: mustWindow(t, tt.window), windowPolicy: tt.policy})
+
+			f := s.next(tt.from)
+			if !f.scheduled.Equal(tt.wantScheduled) || !f.planned.Equal(tt.wantPlanned) {
+				t.Errorf("next(%s) = scheduled %s planned %s, want %s and %s",
+					tt.from, f.scheduled, f.planned, tt.wantScheduled, tt.wantPlanned)
+			}
+		})
+	}
+}
+
+func TestPlannedScheduleJitterStaysInsideWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		window   string
+		jitter   time.Duration
+		from     time.Time
+		earliest time.Time
+		latest   time.Time
+	}{
+		{
+			name: "near window end", spec: "30 59 18 * * *", window: "09:00-19:00", jitter: 10 * time.Minute,
+			from: at(0, 0, 0), earliest: at(18, 59, 30), latest: at(18, 59, 59),
+		},
+		{
+			name: "near window end past midnight", spec: "0 59 5 * * *", window: "22:00-06:00", jitter: time.Hour,
+			from: at(0, 0, 0), earliest: at(5, 59, 0), latest: at(5, 59, 59),
+		},
+		{
+			name: "deferred to window start", spec: "0 0 3 * * *", window: "09:00-19:00", jitter: 30 * time.Minute,
+			from: at(0, 0, 0), earliest: at(9, 0, 0), latest: at(9, 30, 0),
+		},
+		{
+			name: "no window", spec: "0 0 12 * * *", jitter: time.Minute,
+			from: at(0, 0, 0), earliest: at(12, 0, 0), latest: at(12, 1, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := taskOptions{jitter: tt.jitter}
+			if tt.window != "" {
+				o.window = mustWindow(t, tt.window)
+			}
+			s := mustPlan(t, tt.spec, o)
+
+			for range 500 {
+				f := s.next(tt.from)
+				if f.planned.Before(tt.earliest) || f.planned.After(tt.latest) {
+					t.Fatalf("planned %s outside [%s, %s]", f.planned, tt.earliest, tt.latest)
+				}
+			}
+		})
+	}
+}
+
+func TestPlannedScheduleCatchUp(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		policy      WindowPolicy
+		now         time.Time
+		wantPlanned time.Time
+	}{
+		{
+			name: "inside window runs now", spec: "0 30 7 * * *", policy: WindowDrop,
+			now: at(7, 45, 0), wantPlanned: at(7, 45, 0),
+		},
+		{
+			name: "deferred firing still ahead", spec: "0 0 5 * * *", policy: WindowDefer,
+			now: at(6, 0, 0), wantPlanned: at(7, 0, 0),
+		},
+		{
+			name: "outside window deferred to next window", spec: "0 30 7 * * *", policy: WindowDefer,
+			now: at(9, 0, 0), wantPlanned: at(7, 0, 0).AddDate(0, 0, 1),
+		},
+		{
+			name: "outside window dropped", spec: "0 30 7 * * *", policy: WindowDrop,
+			now: at(9, 0, 0),
+		},
+		{
+			name: "firing outside window dropped", spec: "0 0 5 * * *", policy: WindowDrop,
+			now: at(7, 30, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustPlan(t, tt.spec, taskOptions{window: mustWindow(t, "07:00-08:00"), windowPolicy: tt.policy})
+
+			missed := s.base.Next(at(0, 0, 0))
+			f := s.catchUp(missed, tt.now)
+			if !f.planned.Equal(tt.wantPlanned) {
+				t.Errorf("catchUp(%s, %s) planned = %s, want %s", missed, tt.now, f.planned, tt.wantPlanned)
+			}
+			if !tt.wantPlanned.IsZero() && !f.scheduled.Equal(missed) {
+				t.Errorf("catchUp() scheduled = %s, want missed firing %s", f.scheduled, missed)
+			}
+		})
+	}
+}
+
+func TestPlannedSchedulePendingFirings(t *testing.T) {
+	s := mustPlan(t, "0 0 3 * * *", taskOptions{window: mustWindow(t, "09:00-19:00")})
+
+	first := s.Next(at(0, 0, 0))
+	if !first.Equal(at(9, 0, 0)) {
+		t.Fatalf("Next() = %s, want deferred 09:00", first)
+	}
+
+	if _, ok := s.take(at(8, 59, 59)); ok {
+		t.Error("take() before planned time returned a firing")
+	}
+
+	f, ok := s.take(at(9, 0, 0))
+	if !ok || !f.scheduled.Equal(at(3, 0, 0)) || !f.planned.Equal(at(9, 0, 0)) {
+		t.Errorf("take() = %+v, %v, want 03:00 by expression planned at 09:00", f, ok)
+	}
+	if _, ok := s.take(at(9, 0, 0)); ok {
+		t.Error("take() returned the same firing twice")
+	}
+
+	// помнятся только последние maxPendingFirings запусков
+	from := at(0, 0, 0)
+	for range maxPendingFirings + 4 {
+		from = s.Next(from)
+	}
+	if len(s.pending) != maxPendingFirings {
+		t.Fatalf("pending has %d firings, want %d", len(s.pending), maxPendingFirings)
+	}
+	f, _ = s.take(from)
+	if want := at(3, 0, 0).AddDate(0, 0, 4); !f.scheduled.Equal(want) {
+		t.Errorf("oldest kept firing = %s, want %s", f.scheduled, want)
+	}
+}
@@ -0,0 +1,162 @@
+package cron
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/statefile"
+)
+
+// state Переживающее рестарт состояние задач
+type state struct {
+	mu    sync.Mutex
+	store statefile.Store
+	Tasks map[string]*taskState `json:"tasks"`
+}
+
+type taskState struct {
+	// LastRun Время начала последнего успешного запуска, Runs - сколько всего было успешных запусков
+	LastRun time.Time `json:"last_run,omitzero"`
+	Runs    int64     `json:"runs,omitempty"`
+	// Since С какого момента задача отслеживается, база для поиска пропусков до первого запуска
+	Since time.Time `json:"since,omitzero"`
+	// Paused Задача на паузе; при нулевом PausedUntil - до явного Resume
+	Paused      bool      `json:"paused,omitempty"`
+	PausedUntil time.Time `json:"paused_until,omitzero"`
+	// OneShotAt Время запуска разовой задачи, FiredAt - когда она успешно выполнилась
+	OneShotAt time.Time `json:"one_shot_at,omitzero"`
+	FiredAt   time.Time `json:"fired_at,omitzero"`
+}
+
+// pausedAt сообщает, стоит ли задача на паузе в момент t
+func (ts taskState) pausedAt(t time.Time) bool {
+	if !ts.Paused {
+		return false
+	}
+	return ts.PausedUntil.IsZero() || t.Before(ts.PausedUntil)
+}
+
+func newState() *state {
+	return &state{Tasks: make(map[string]*taskState)}
+}
+
+func loadState(path string) (*state, error) {
+	s := &state{store: statefile.NewStore(path, "cron state")}
+	if err := s.store.Load(s); err != nil {
+		return nil, err
+	}
+	if s.Tasks == nil {
+		s.Tasks = make(map[string]*taskState)
+	}
+
+	return s, nil
+}
+
+// track заводит запись о задаче, если ее еще нет, и возвращает копию
+func (s *state) track(name string, now time.Time) taskState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.Tasks[name]
+	if !ok {
+		ts = &taskState{Since: now}
+		s.Tasks[name] = ts
+		s.saveLocked()
+	}
+
+	return *ts
+}
+
+func (s *state) setLastRun(name string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.Tasks[name]
+	if !ok {
+		ts = &taskState{Since: t}
+		s.Tasks[name] = ts
+	}
+	ts.LastRun = t
+	ts.Runs++
+	s.saveLocked()
+}
+
+func (s *state) setPause(name string, paused bool, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.Tasks[name]
+	if !ok {
+		ts = &taskState{Since: time.Now()}
+		s.Tasks[name] = ts
+	}
+	ts.Paused = paused
+	ts.PausedUntil = until
+	s.saveLocked()
+}
+
+func (s *state) setOneShot(name string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.Tasks[name]
+	if !ok {
+		ts = &taskState{Since: time.Now()}
+		s.Tasks[name] = ts
+	}
+	if ts.OneShotAt.Equal(at) {
+		return
+	}
+	ts.OneShotAt = at
+	ts.FiredAt = time.Time{}
+	s.saveLocked()
+}
+
+func (s *state) setOneShotFired(name string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ts, ok := s.Tasks[name]; ok {
+		ts.FiredAt = t
+		s.saveLocked()
+	}
+}
+
+func (s *state) oneShotFired(name string, at time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.Tasks[name]
+	return ok && ts.OneShotAt.Equal(at) && !ts.FiredAt.IsZero()
+}
+
+func (s *state) forgetPrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for name := range s.Tasks {
+		if strings.HasPrefix(name, prefix) {
+			delete(s.Tasks, name)
+			changed = true
+		}
+	}
+	if changed {
+		s.saveLocked()
+	}
+}
+
+func (s *state) get(name string) taskState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ts, ok := s.Tasks[name]; ok {
+		return *ts
+	}
+	return taskState{}
+}
+
+func (s *state) saveLocked() {
+	s.store.SaveOrLog(s)
+}
@@ -0,0 +1,108 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WindowPolicy Что делать с запуском, выпавшим за окно отправки
+type WindowPolicy int
+
+const (
+	// WindowDefer запуск переносится на начало ближайшего окна
+	WindowDefer WindowPolicy = iota
+	// WindowDrop запуск отбрасывается
+	WindowDrop
+)
+
+func (p WindowPolicy) String() string {
+	if p == WindowDrop {
+		return "drop"
+	}
+	return "defer"
+}
+
+// ParseWindowPolicy разбирает политику из конфига. Пустая строка означает defer
+func ParseWindowPolicy(s string) (WindowPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "defer":
+		return WindowDefer, nil
+	case "drop":
+		return WindowDrop, nil
+	default:
+		return WindowDefer, fmt.Errorf("unknown window policy %q", s)
+	}
+}
+
+// SendWindow Разрешенный интервал времени суток. Если End не больше Start, окно переходит через полночь
+type SendWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseSendWindow разбирает окно вида "09:00-19:00"
+func ParseSendWindow(s string) (SendWindow, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return SendWindow{}, fmt.Errorf("invalid send window %q, expected HH:MM-HH:MM", s)
+	}
+
+	start, err := parseClock(startStr)
+	if err != nil {
+		return SendWindow{}, fmt.Errorf("invalid send window %q: %w", s, err)
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return SendWindow{}, fmt.Errorf("invalid send window %q: %w", s, err)
+	}
+
+	return SendWindow{Start: start, End: end}, nil
+}
+
+func (w SendWindow) String() string {
+	return formatClock(w.Start) + "-" + formatClock(w.End)
+}
+
+func (w SendWindow) contains(t time.Time) bool {
+	offset := t.Sub(midnight(t))
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// nextStart возвращает ближайшее начало окна не раньше t
+func (w SendWindow) nextStart(t time.Time) time.Time {
+	start := midnight(t).Add(w.Start)
+	if start.Before(t) {
+		start = midnight(t.AddDate(0, 0, 1)).Add(w.Start)
+	}
+	return start
+}
+
+// end возвращает конец окна, в которое попадает t
+func (w SendWindow) end(t time.Time) time.Time {
+	end := midnight(t).Add(w.End)
+	if !end.After(t) {
+		end = midnight(t.AddDate(0, 0, 1)).Add(w.End)
+	}
+	return end
+}
+
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func parseClock(s string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,202 @@
+package instancelock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/psevdocoder/gentleman-ping-bot/pkg/statefile"
+)
+
+// ErrLeaseLost Аренду перехватил другой экземпляр или продлить ее не удалось
+var ErrLeaseLost = errors.New("instance lease lost")
+
+// Lock Аренда единственного активного экземпляра бота на файле в общем томе.
+// Чтение и запись файла аренды защищены flock, а сама аренда имеет срок действия,
+// поэтому упавший без освобождения экземпляр не блокирует остальные дольше ttl
+type Lock struct {
+	path  string
+	owner string
+	ttl   time.Duration
+}
+
+type lease struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// minTTL Самый короткий срок аренды: продление идет каждые ttl/3, и на него нужно время
+const minTTL = time.Second
+
+func NewLock(path string, owner string, ttl time.Duration) (*Lock, error) {
+	if ttl < minTTL {
+		return nil, fmt.Errorf("instance lease ttl must be at least %s, got %s", minTTL, ttl)
+	}
+
+	return &Lock{
+		path:  path,
+		owner: owner,
+		ttl:   ttl,
+	}, nil
+}
+
+// Owner возвращает идентификатор этого экземпляра
+func (l *Lock) Owner() string {
+	return l.owner
+}
+
+// TryAcquire захватывает аренду, если она свободна, истекла или уже принадлежит нам
+func (l *Lock) TryAcquire() (bool, error) {
+	acquired := false
+
+	err := l.update(func(current lease, now time.Time) (lease, bool) {
+		if current.Owner != "" && current.Owner != l.owner && now.Before(current.ExpiresAt) {
+			return current, false
+		}
+
+		acquired = true
+		return lease{Owner: l.owner, ExpiresAt: now.Add(l.ttl)}, true
+	})
+
+	return acquired, err
+}
+
+// Renew продлевает аренду. Возвращает ErrLeaseLost, если аренда уже не наша
+func (l *Lock) Renew() error {
+	lost := false
+
+	err := l.update(func(current lease, now time.Time) (lease, bool) {
+		if current.Owner != l.owner {
+			lost = true
+			return current, false
+		}
+
+		return lease{Owner: l.owner, ExpiresAt: now.Add(l.ttl)}, true
+	})
+	if err != nil {
+		return err
+	}
+	if lost {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+// Release освобождает аренду, если она наша, чтобы резервный экземпляр не ждал истечения ttl
+func (l *Lock) Release() error {
+	return l.update(func(current lease, _ time.Time) (lease, bool) {
+		if current.Owner != l.owner {
+			return current, false
+		}
+		return lease{}, true
+	})
+}
+
+// Wait пытается захватить аренду каждые ttl/3, пока не получится или не отменят ctx
+func (l *Lock) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	logged := false
+	for {
+		acquired, err := l.TryAcquire()
+		if err != nil {
+			log.Printf("Failed to acquire instance lease: %v", err)
+		}
+		if acquired {
+			return nil
+		}
+
+		if !logged {
+			log.Printf("Instance lease %s is held by another instance, waiting in standby", l.path)
+			logged = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// KeepAlive продлевает аренду каждые ttl/3 до отмены ctx. Если продлить не удалось
+// до истечения аренды, вызывает onLost и завершается
+func (l *Lock) KeepAlive(ctx context.Context, onLost func(err error)) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	lastRenewed := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := l.Renew()
+		if err == nil {
+			lastRenewed = time.Now()
+			continue
+		}
+
+		if errors.Is(err, ErrLeaseLost) || time.Since(lastRenewed) >= l.ttl {
+			onLost(err)
+			return
+		}
+
+		log.Printf("Failed to renew instance lease, will retry: %v", err)
+	}
+}
+
+// update читает аренду под flock, передает ее в fn и записывает результат, если fn того просит
+func (l *Lock) update(fn func(current lease, now time.Time) (lease, bool)) error {
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := statefile.LockFile(file); err != nil {
+		return fmt.Errorf("lock %s: %w", l.path, err)
+	}
+	defer statefile.UnlockFile(file)
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+
+	var current lease
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &current); err != nil {
+			log.Printf("Instance lease %s is corrupted, treating as free: %v", l.path, err)
+			current = lease{}
+		}
+	}
+
+	next, write := fn(current, time.Now())
+	if !write {
+		return nil
+	}
+
+	out, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(out, 0); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
@@ -29,6 +29,15 @@ func (v Value) Bool() (bool, error)              { return toBool(v.raw) }
 func (v Value) String() (string, error)          { return toString(v.raw) }
 func (v Value) Duration() (time.Duration, error) { return toDuration(v.raw) }
 
+// Decode раскладывает значение в структуру через yaml-теги
+func (v Value) Decode(out any) error {
+	data, err := yaml.Marshal(v.raw)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, out)
+}
+
 type WatchCallback func(newValue, oldValue Value)
 
 const defaultConfigPath = "values/config.yaml"
@@ -36,7 +45,18 @@ const defaultConfigPath = "values/config.yaml"
 var (
 	callbacks  = make(map[Key][]WatchCallback)
 	lastValues = make(map[Key]Value)
-	mu         sync.RWMutex
+
+	sectionCallbacks = make(map[string][]WatchCallback)
+	lastSections     = make(map[string]Value)
+
+	mu sync.RWMutex
+	// deliverMu держится от сравнения с прошлыми значениями до конца вызова подписчиков,
+	// поэтому изменения доходят до них по одному и в порядке сохранения файла
+	deliverMu sync.Mutex
+
+	watchersMu sync.Mutex
+	watchers   []*fsnotify.Watcher
+	watchersWg sync.WaitGroup
 )
 
 func Watch(key Key, callback WatchCallback) {
@@ -45,6 +65,19 @@ func Watch(key Key, callback WatchCallback) {
 	callbacks[key] = append(callbacks[key], callback)
 }
 
+// WatchSection подписывается на изменения целой секции верхнего уровня (например, jobs).
+// Подписчики вызываются по очереди из горутины наблюдателя, поэтому последнее сохранение применяется последним
+func WatchSection(section string, callback WatchCallback) {
+	mu.Lock()
+	defer mu.Unlock()
+	sectionCallbacks[section] = append(sectionCallbacks[section], callback)
+}
+
+// GetSection возвращает секцию верхнего уровня целиком
+func GetSection(section string) (Value, error) {
+	return getSectionFromPath(defaultConfigPath, section)
+}
+
 func StartWatching() error {
 	return startWithPath(defaultConfigPath)
 }
@@ -64,23 +97,63 @@ func startWithPath(path string) error {
 	}
 
 	if err := watcher.Add(path); err != nil {
+		_ = watcher.Close()
 		return err
 	}
 
+	runWatcher(watcher, func(event fsnotify.Event) {
+		if event.Op&(fsnotify.Write|fsnotify.Create) > 0 {
+			checkForChanges(path)
+		}
+	})
+
+	return nil
+}
+
+// StopWatching закрывает все fsnotify-наблюдатели и дожидается завершения их горутин
+func StopWatching() error {
+	watchersMu.Lock()
+	current := watchers
+	watchers = nil
+	watchersMu.Unlock()
+
+	var errs []error
+	for _, watcher := range current {
+		if err := watcher.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	watchersWg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// runWatcher регистрирует наблюдатель и обрабатывает его события, пока он не будет закрыт
+func runWatcher(watcher *fsnotify.Watcher, handle func(event fsnotify.Event)) {
+	watchersMu.Lock()
+	watchers = append(watchers, watcher)
+	watchersMu.Unlock()
+
+	watchersWg.Add(1)
 	go func() {
+		defer watchersWg.Done()
+
 		for {
 			select {
-			case event := <-watcher.Events:
-				if event.Op&(fsnotify.Write|fsnotify.Create) > 0 {
-					checkForChanges(path)
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				handle(event)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
 				}
-			case err := <-watcher.Errors:
 				log.Printf("fsnotify error: %v", err)
 			}
 		}
 	}()
-
-	return nil
 }
 
 func getFromPath(path string, key Key) (Value, error) {
@@ -132,6 +205,25 @@ func getFromPath(path string, key Key) (Value, error) {
 	return Value{}, fmt.Errorf("key not found: %s", key)
 }
 
+func getSectionFromPath(path string, section string) (Value, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Value{}, err
+	}
+
+	var full map[string]any
+	if err := yaml.Unmarshal(data, &full); err != nil {
+		return Value{}, err
+	}
+
+	raw, ok := full[section]
+	if !ok {
+		return Value{}, fmt.Errorf("section not found: %s", section)
+	}
+
+	return Value{raw}, nil
+}
+
 func loadInitial(path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -142,11 +234,14 @@ func loadInitial(path string) error {
 		return err
 	}
 	rawList, ok := full["realtime_config"].([]any)
-	if !ok {
-		return errors.New("invalid or missing realtime_config section")
+	if !ok && full["realtime_config"] != nil {
+		return errors.New("invalid realtime_config section")
 	}
 	mu.Lock()
 	defer mu.Unlock()
+	for section, raw := range full {
+		lastSections[section] = Value{raw}
+	}
 	for _, item := range rawList {
 		entry, ok := item.(map[string]any)
 		if !ok {
@@ -173,14 +268,37 @@ func checkForChanges(path string) {
 		log.Printf("error parsing yaml: %v", err)
 		return
 	}
+	if len(full) == 0 {
+		// файл пустой или перезаписывается прямо сейчас, ждем следующего события
+		return
+	}
 	rawList, ok := full["realtime_config"].([]any)
-	if !ok {
-		log.Printf("invalid or missing realtime_config section")
+	if !ok && full["realtime_config"] != nil {
+		log.Printf("invalid realtime_config section")
 		return
 	}
 
+	deliverMu.Lock()
+	defer deliverMu.Unlock()
+
+	var deliveries []func()
+	notify := func(cbs []WatchCallback, v, oldVal Value) {
+		for _, cb := range cbs {
+			deliveries = append(deliveries, func() { cb(v, oldVal) })
+		}
+	}
+
 	mu.Lock()
-	defer mu.Unlock()
+	for section, cbs := range sectionCallbacks {
+		oldVal := lastSections[section]
+		val := full[section]
+		if equal(oldVal.raw, val) {
+			continue
+		}
+		v := Value{val}
+		lastSections[section] = v
+		notify(cbs, v, oldVal)
+	}
 	for _, item := range rawList {
 		entry, ok := item.(map[string]any)
 		if !ok {
@@ -196,11 +314,16 @@ func checkForChanges(path string) {
 		v := Value{val}
 		if !exists || !equal(oldVal.raw, val) {
 			lastValues[key] = v
-			for _, cb := range callbacks[key] {
-				go cb(v, oldVal)
-			}
+			notify(callbacks[key], v, oldVal)
 		}
 	}
+	mu.Unlock()
+
+	// подписчики вызываются вне mu, чтобы из них можно было читать конфиг и подписываться,
+	// и последовательно: следующее сохранение не обгонит предыдущее
+	for _, deliver := range deliveries {
+		deliver()
+	}
 }
 
 func toInt(v any) (int, error) {
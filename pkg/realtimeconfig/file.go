@@ -0,0 +1,34 @@
+package realtimeconfig
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchFile следит за произвольным файлом рядом с конфигом и вызывает callback при каждом его изменении.
+// Наблюдение идет за директорией, поэтому замена файла через rename тоже отслеживается
+func WatchFile(path string, callback func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	target := filepath.Clean(path)
+
+	runWatcher(watcher, func(event fsnotify.Event) {
+		if filepath.Clean(event.Name) != target {
+			return
+		}
+		if event.Op&(fsnotify.Write|fsnotify.Create) > 0 {
+			callback()
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package statefile
+
+import "os"
+
+// На платформах без flock файлы не блокируются между процессами
+func LockFile(*os.File) error { return nil }
+
+func UnlockFile(*os.File) {}
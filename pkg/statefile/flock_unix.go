@@ -0,0 +1,17 @@
+//go:build unix
+
+package statefile
+
+import (
+	"os"
+	"syscall"
+)
+
+// LockFile берет эксклюзивный flock на открытый файл, ожидая, пока его отпустит другой процесс
+func LockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+}
+
+func UnlockFile(file *os.File) {
+	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
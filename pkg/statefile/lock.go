@@ -0,0 +1,29 @@
+package statefile
+
+import (
+	"fmt"
+	"os"
+)
+
+// Locked выполняет fn под межпроцессной блокировкой файла состояния, чтобы чтение, изменение
+// и запись в одном процессе не перемешались с другим, например с командой CLI при работающем боте.
+// Блокируется соседний файл path+".lock", потому что сам файл Save подменяет через rename.
+// Без пути fn выполняется без блокировки
+func Locked(path string, fn func() error) error {
+	if path == "" {
+		return fn()
+	}
+
+	file, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := LockFile(file); err != nil {
+		return fmt.Errorf("lock %s: %w", path, err)
+	}
+	defer UnlockFile(file)
+
+	return fn()
+}
@@ -0,0 +1,51 @@
+package statefile
+
+import "log"
+
+// Store Файл, в котором состояние переживает рестарт. Пустой путь означает состояние
+// только в памяти: Load и Save тогда ничего не делают
+type Store struct {
+	path string
+	// name Что хранится в файле, для сообщения об ошибке сохранения
+	name string
+}
+
+func NewStore(path string, name string) Store {
+	return Store{path: path, name: name}
+}
+
+// InMemory сообщает, что файл не задан
+func (s Store) InMemory() bool {
+	return s.path == ""
+}
+
+// Load читает состояние в v. Отсутствие файла ошибкой не считается, v остается как есть
+func (s Store) Load(v any) error {
+	if s.InMemory() {
+		return nil
+	}
+
+	return Load(s.path, v)
+}
+
+// Save атомарно записывает состояние
+func (s Store) Save(v any) error {
+	if s.InMemory() {
+		return nil
+	}
+
+	return Save(s.path, v)
+}
+
+// SaveOrLog записывает состояние, а ошибку пишет в лог: сохранение идет по ходу работы,
+// и вернуть ее некуда. Состояние в памяти при этом остается верным
+func (s Store) SaveOrLog(v any) {
+	if err := s.Save(v); err != nil {
+		log.Printf("Failed to save %s: %v", s.name, err)
+	}
+}
+
+// Locked выполняет fn под межпроцессной блокировкой файла, см. Locked
+func (s Store) Locked(fn func() error) error {
+	return Locked(s.path, fn)
+}
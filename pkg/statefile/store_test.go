@@ -0,0 +1,40 @@
+package statefile
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+type testState struct {
+	Items map[string]int `json:"items"`
+}
+
+func TestStoreInMemory(t *testing.T) {
+	store := NewStore("", "state")
+	if err := store.Save(testState{Items: map[string]int{"a": 1}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var s testState
+	if err := store.Load(&s); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if s.Items != nil {
+		t.Errorf("Load() Items = %v, want nothing loaded in memory", s.Items)
+	}
+}
+
+func TestStoreSaveRoundTrip(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state.json"), "state")
+	if err := store.Save(testState{Items: map[string]int{"a": 1}}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	var s testState
+	if err := store.Load(&s); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if s.Items["a"] != 1 {
+		t.Errorf("Load() Items = %v, want a=1", s.Items)
+	}
+}